@@ -0,0 +1,133 @@
+package command
+
+import (
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"time"
+)
+
+type ArgumentType int
+
+const (
+	StringArgument ArgumentType = iota
+	IntArgument
+	FloatArgument
+	BoolArgument
+	DurationArgument
+)
+
+func (t ArgumentType) String() string {
+	switch t {
+	case IntArgument:
+		return "int"
+	case FloatArgument:
+		return "float"
+	case BoolArgument:
+		return "bool"
+	case DurationArgument:
+		return "duration"
+	default:
+		return "string"
+	}
+}
+
+// Argument describes a positional argument.
+// A Variadic argument consumes every remaining positional argument and must be the last one
+type Argument struct {
+	Name     string
+	Type     ArgumentType
+	Required bool
+	Default  interface{}
+	Variadic bool
+}
+
+// Flag describes a named argument passed as --name value or --name=value.
+// A BoolArgument flag can be passed without a value
+type Flag struct {
+	Name     string
+	Type     ArgumentType
+	Required bool
+	Default  interface{}
+}
+
+// Schema declares the arguments a command accepts
+type Schema struct {
+	Arguments []Argument
+	Flags     []Flag
+}
+
+func (s *Schema) flag(name string) (Flag, bool) {
+	for _, flag := range s.Flags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return Flag{}, false
+}
+
+// ArgumentsExecutable can be implemented alongside Executable to have the SDK validate the arguments of a command.
+// ExecuteArguments is then called instead of Execute with the parsed Arguments
+type ArgumentsExecutable interface {
+	Schema() *Schema
+	ExecuteArguments(command *domain.CommandMessage, arguments *Arguments) ([]*domain.ClientMessage, error)
+}
+
+// Arguments holds the values parsed from a CommandMessage according to a Schema
+type Arguments struct {
+	values map[string]interface{}
+}
+
+func newArguments() *Arguments {
+	return &Arguments{values: map[string]interface{}{}}
+}
+
+// Has returns true if the argument was passed or has a default value
+func (a *Arguments) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+func (a *Arguments) Value(name string) interface{} {
+	return a.values[name]
+}
+
+func (a *Arguments) String(name string) string {
+	v, _ := a.values[name].(string)
+	return v
+}
+
+func (a *Arguments) Int(name string) int {
+	v, _ := a.values[name].(int)
+	return v
+}
+
+func (a *Arguments) Float(name string) float64 {
+	v, _ := a.values[name].(float64)
+	return v
+}
+
+func (a *Arguments) Bool(name string) bool {
+	v, _ := a.values[name].(bool)
+	return v
+}
+
+func (a *Arguments) Duration(name string) time.Duration {
+	v, _ := a.values[name].(time.Duration)
+	return v
+}
+
+// List returns the values of a variadic argument
+func (a *Arguments) List(name string) []interface{} {
+	v, _ := a.values[name].([]interface{})
+	return v
+}
+
+// Strings returns the values of a variadic argument formatted as strings
+func (a *Arguments) Strings(name string) []string {
+	values := a.List(name)
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = fmt.Sprint(value)
+	}
+	return strs
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"fmt"
 	"github.com/raf924/connector-sdk/domain"
 	"log"
 	"reflect"
@@ -8,18 +9,44 @@ import (
 
 var commands = NewCommandList()
 
-func HandleCommand(command Command) {
-	log.Println("Handling", command.Name())
+// HandleCommand registers a command in the global list returned by GetCommandList.
+// It returns an error if the command is not a pointer, has an invalid name or conflicts with an already registered command
+func HandleCommand(command Command) error {
+	name := nameOf(command)
+	log.Println("Handling", name)
 	if reflect.TypeOf(command).Kind() != reflect.Ptr {
-		log.Println("command must be a pointer type")
+		return fmt.Errorf("command %s must be a pointer type", name)
 	}
-	commands.Add(command)
+	return commands.Add(command)
+}
+
+// UnhandleCommand unregisters a command from the global list returned by GetCommandList
+func UnhandleCommand(command string) error {
+	log.Println("Unhandling", command)
+	return commands.Remove(command)
 }
 
 func GetCommandList() List {
 	return commands
 }
 
+// InitCommand initializes a Command and its subcommands
+func InitCommand(bot Executor, command Command) error {
+	if err := command.Init(bot); err != nil {
+		return err
+	}
+	if parent, ok := command.(Parent); ok {
+		for _, sub := range parent.Subcommands() {
+			if err := InitCommand(bot, sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var _ = InitCommand
+
 type Executor interface {
 	BotUser() *domain.User
 	ApiKeys() map[string]string
@@ -1,6 +1,10 @@
 package command
 
 import (
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"github.com/raf924/connector-sdk/internal/commandindex"
+	"log"
 	"strings"
 	"sync"
 )
@@ -9,7 +13,11 @@ type List interface {
 	Range(f func(command Command) bool)
 	Get(i int) Command
 	Find(command string) Command
-	Add(command Command)
+	Add(command Command) error
+	// Remove unregisters the command matching a name or an alias
+	Remove(command string) error
+	// Replace swaps the command registered under the Name of replacement, keeping its position in the list
+	Replace(replacement Command) error
 }
 
 var _ List = (*immutableCommandList)(nil)
@@ -20,7 +28,15 @@ type immutableCommandList struct {
 	List
 }
 
-func (i *immutableCommandList) Add(Command) {
+func (i *immutableCommandList) Add(Command) error {
+	panic("cannot modify list")
+}
+
+func (i *immutableCommandList) Remove(string) error {
+	panic("cannot modify list")
+}
+
+func (i *immutableCommandList) Replace(Command) error {
 	panic("cannot modify list")
 }
 
@@ -34,14 +50,79 @@ func ImmutableCommandList(cl List) List {
 
 var _ = ImmutableCommandList
 
+// executionTracker is implemented by lists counting the running executions of their commands,
+// so that a replaced or removed command is only shut down once they return
+type executionTracker interface {
+	// track registers an execution of a command of the list and returns the function ending it.
+	// It returns false if the command is not registered anymore
+	track(command Command) (func(), bool)
+	// idle returns a channel closed once command has no running execution
+	idle(command Command) <-chan struct{}
+}
+
+var _ executionTracker = (*commandList)(nil)
+
+type executions struct {
+	running int
+	idle    chan struct{}
+}
+
 type commandList struct {
-	rwm            *sync.RWMutex
-	commands       []Command
-	commandIndexes map[string]int
+	rwm   *sync.RWMutex
+	index *commandindex.Index[Command]
+	// em guards executions, it may be locked while rwm is held
+	em         *sync.Mutex
+	executions map[Command]*executions
+}
+
+func (l *commandList) track(command Command) (func(), bool) {
+	l.rwm.RLock()
+	registered, _ := l.index.Find(nameOf(command))
+	ok := registered != nil && interface{}(registered) == interface{}(command)
+	if ok {
+		l.em.Lock()
+		e := l.executions[command]
+		if e == nil {
+			e = &executions{idle: make(chan struct{})}
+			l.executions[command] = e
+		}
+		e.running++
+		l.em.Unlock()
+	}
+	l.rwm.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return func() {
+		l.em.Lock()
+		e := l.executions[command]
+		e.running--
+		if e.running == 0 {
+			close(e.idle)
+			delete(l.executions, command)
+		}
+		l.em.Unlock()
+	}, true
+}
+
+func (l *commandList) idle(command Command) <-chan struct{} {
+	l.em.Lock()
+	e := l.executions[command]
+	l.em.Unlock()
+	if e == nil {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	return e.idle
 }
 
+// Range iterates over a snapshot of the list so that f may modify it
 func (l *commandList) Range(f func(command Command) bool) {
-	for _, command := range l.commands {
+	l.rwm.RLock()
+	commands := l.index.Commands()
+	l.rwm.RUnlock()
+	for _, command := range commands {
 		if !f(command) {
 			break
 		}
@@ -50,50 +131,90 @@ func (l *commandList) Range(f func(command Command) bool) {
 
 func (l *commandList) Get(i int) Command {
 	l.rwm.RLock()
-	command := l.commands[i]
+	command := l.index.Get(i)
 	l.rwm.RUnlock()
 	return command
 }
 
+// Find returns the command matching a name or an alias.
+// A space separated path (e.g. "quote add") resolves the deepest matching subcommand
 func (l *commandList) Find(command string) Command {
+	path := strings.Fields(command)
+	if len(path) == 0 {
+		return nil
+	}
 	l.rwm.RLock()
-	actualCommand := func() Command {
-		i, ok := l.commandIndexes[command]
-		if !ok {
-			return nil
-		}
-		return l.commands[i]
-	}()
+	actualCommand, _ := l.index.Find(path[0])
 	l.rwm.RUnlock()
-	return actualCommand
+	if actualCommand == nil {
+		return nil
+	}
+	chain := resolve(actualCommand, path[1:])
+	return chain[len(chain)-1]
 }
 
-func (l *commandList) Add(command Command) {
-	aliases := append(command.Aliases(), command.Name())
-	if len(strings.TrimSpace(command.Name())) == 0 {
-		return
+// Add registers a command after checking the names and aliases of its subcommand tree.
+// Names conflicting with already registered commands are handled according to the list's domain.ConflictPolicy
+func (l *commandList) Add(command Command) error {
+	description, err := describeSafely(command)
+	if err != nil {
+		return fmt.Errorf("invalid command %T: %w", command, err)
+	}
+	if err := domain.ValidateCommand(description); err != nil {
+		return err
 	}
 	l.rwm.Lock()
-	func() {
-		l.commands = append(l.commands, command)
-		for _, alias := range aliases {
-			if len(strings.TrimSpace(alias)) == 0 {
-				return
-			}
-			l.commandIndexes[alias] = len(l.commands) - 1
-		}
-	}()
+	err = l.index.Add(command, append([]string{description.Name()}, description.Aliases()...))
 	l.rwm.Unlock()
+	return err
 }
 
-func NewCommandList(commands ...Command) List {
+func (l *commandList) Remove(command string) error {
+	l.rwm.Lock()
+	err := l.index.Remove(command)
+	l.rwm.Unlock()
+	return err
+}
+
+// Replace fails if the replacement conflicts with another command, whatever the list's domain.ConflictPolicy
+func (l *commandList) Replace(replacement Command) error {
+	description, err := describeSafely(replacement)
+	if err != nil {
+		return fmt.Errorf("invalid command %T: %w", replacement, err)
+	}
+	if err := domain.ValidateCommand(description); err != nil {
+		return err
+	}
+	l.rwm.Lock()
+	err = l.index.Replace(replacement, append([]string{description.Name()}, description.Aliases()...))
+	l.rwm.Unlock()
+	return err
+}
+
+// NewCommandListWithPolicy creates a List resolving conflicts with policy.
+// It stops at the first command that cannot be added
+func NewCommandListWithPolicy(policy domain.ConflictPolicy, commands ...Command) (List, error) {
 	ul := &commandList{
-		commands:       []Command{},
-		commandIndexes: map[string]int{},
-		rwm:            &sync.RWMutex{},
+		rwm:        &sync.RWMutex{},
+		index:      commandindex.New(policy == domain.LastWins, nameOf),
+		em:         &sync.Mutex{},
+		executions: map[Command]*executions{},
+	}
+	for _, command := range commands {
+		if err := ul.Add(command); err != nil {
+			return ul, err
+		}
 	}
+	return ul, nil
+}
+
+// NewCommandList creates a List rejecting conflicting commands. Commands that cannot be added are logged and skipped
+func NewCommandList(commands ...Command) List {
+	ul, _ := NewCommandListWithPolicy(domain.RejectConflicts)
 	for _, command := range commands {
-		ul.Add(command)
+		if err := ul.Add(command); err != nil {
+			log.Println(err)
+		}
 	}
 	return ul
 }
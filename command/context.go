@@ -0,0 +1,73 @@
+package command
+
+import (
+	"context"
+	"github.com/raf924/connector-sdk/domain"
+	"github.com/segmentio/ksuid"
+	"time"
+)
+
+// ContextExecutable can be implemented alongside Executable to receive a context cancelled
+// when the execution times out or the Invoker shuts down. ExecuteContext is then called instead of Execute
+type ContextExecutable interface {
+	ExecuteContext(ctx context.Context, command *domain.CommandMessage) ([]*domain.ClientMessage, error)
+}
+
+// ContextArgumentsExecutable is the context aware variant of ArgumentsExecutable
+type ContextArgumentsExecutable interface {
+	Schema() *Schema
+	ExecuteArgumentsContext(ctx context.Context, command *domain.CommandMessage, arguments *Arguments) ([]*domain.ClientMessage, error)
+}
+
+// ContextInterceptor can be implemented alongside Interceptor to receive a context.
+// OnChatContext and OnUserEventContext are then called instead of OnChat and OnUserEvent
+type ContextInterceptor interface {
+	OnChatContext(ctx context.Context, message *domain.ChatMessage) ([]*domain.ClientMessage, error)
+	OnUserEventContext(ctx context.Context, event *domain.UserEvent) ([]*domain.ClientMessage, error)
+}
+
+// TimeLimited can be implemented by a command to override the timeout of the Invoker
+type TimeLimited interface {
+	Timeout() time.Duration
+}
+
+func executeContext(ctx context.Context, cmd Command, message *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	if executable, ok := cmd.(ContextExecutable); ok {
+		return executable.ExecuteContext(ctx, message)
+	}
+	return cmd.Execute(message)
+}
+
+func onChatContext(ctx context.Context, cmd Command, message *domain.ChatMessage) ([]*domain.ClientMessage, error) {
+	if interceptor, ok := cmd.(ContextInterceptor); ok {
+		return interceptor.OnChatContext(ctx, message)
+	}
+	return cmd.OnChat(message)
+}
+
+func onUserEventContext(ctx context.Context, cmd Command, event *domain.UserEvent) ([]*domain.ClientMessage, error) {
+	if interceptor, ok := cmd.(ContextInterceptor); ok {
+		return interceptor.OnUserEventContext(ctx, event)
+	}
+	return cmd.OnUserEvent(event)
+}
+
+type correlationIdKey struct{}
+
+// WithCorrelationId returns a context carrying a correlation id identifying a request across logs and commands
+func WithCorrelationId(ctx context.Context, correlationId string) context.Context {
+	return context.WithValue(ctx, correlationIdKey{}, correlationId)
+}
+
+// CorrelationId returns the correlation id of a context. The Invoker sets one if the caller did not
+func CorrelationId(ctx context.Context) string {
+	correlationId, _ := ctx.Value(correlationIdKey{}).(string)
+	return correlationId
+}
+
+func ensureCorrelationId(ctx context.Context) context.Context {
+	if len(CorrelationId(ctx)) > 0 {
+		return ctx
+	}
+	return WithCorrelationId(ctx, ksuid.New().String())
+}
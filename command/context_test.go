@@ -0,0 +1,53 @@
+package command
+
+import (
+	"context"
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+type contextCommand struct {
+	testCommand
+	timeout time.Duration
+	started chan struct{}
+}
+
+func (c *contextCommand) Timeout() time.Duration {
+	return c.timeout
+}
+
+func (c *contextCommand) ExecuteContext(ctx context.Context, command *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	if c.started != nil {
+		close(c.started)
+	}
+	<-ctx.Done()
+	return []*domain.ClientMessage{domain.NewClientMessage(CorrelationId(ctx)+":"+ctx.Err().Error(), command.Sender(), command.Private())}, nil
+}
+
+func TestInvoker_Context(t *testing.T) {
+	slower := &contextCommand{testCommand: testCommand{name: "slower"}, started: make(chan struct{})}
+	list := NewCommandList(&contextCommand{testCommand: testCommand{name: "slow"}, timeout: 10 * time.Millisecond}, slower)
+	invoker := NewInvoker(&testExecutor{}, list, WithTimeout(time.Hour))
+	message, _ := NewCommandMessage("slow", "", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	replies, _ := invoker.Execute(WithCorrelationId(context.Background(), "request"), message)
+	if len(replies) != 1 || replies[0].Message() != "request:"+context.DeadlineExceeded.Error() {
+		t.Errorf("expected command to time out with the correlation id got %v", replies)
+	}
+
+	result := make(chan []*domain.ClientMessage)
+	go func() {
+		message, _ := NewCommandMessage("slower", "", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+		replies, _ := invoker.Execute(context.Background(), message)
+		result <- replies
+	}()
+	<-slower.started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_ = invoker.Shutdown(ctx)
+	replies = <-result
+	if len(replies) != 1 || !strings.HasSuffix(replies[0].Message(), context.Canceled.Error()) {
+		t.Errorf("expected in-flight command to be cancelled on shutdown got %v", replies)
+	}
+}
@@ -0,0 +1,186 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"log"
+	"sync"
+	"time"
+)
+
+// A ConversationHandler receives the next chat messages of a user. Returning done ends the conversation
+type ConversationHandler func(ctx context.Context, message *domain.ChatMessage) (replies []*domain.ClientMessage, done bool, err error)
+
+type Conversation struct {
+	Handler ConversationHandler
+	// Timeout ends the conversation when the user does not answer in time. It is reset by every message
+	Timeout time.Duration
+	// OnTimeout builds the messages sent when the conversation times out. It may be nil
+	OnTimeout func() []*domain.ClientMessage
+}
+
+// A ConversationManager lets commands claim the next chat messages of a user to implement multi-step prompts.
+// A user has at most one conversation in private and one in each public channel
+type ConversationManager interface {
+	// Open starts a conversation with user, replacing any pending one. The channel is ignored for private conversations and may be nil
+	Open(user *domain.User, channel *domain.Channel, private bool, conversation Conversation) error
+	Close(user *domain.User, channel *domain.Channel, private bool)
+	Active(user *domain.User, channel *domain.Channel, private bool) bool
+	// Handle passes a ChatMessage to the conversation of its sender. It returns false if the sender has none
+	Handle(ctx context.Context, message *domain.ChatMessage) ([]*domain.ClientMessage, bool, error)
+}
+
+var _ ConversationManager = (*conversationManager)(nil)
+
+type conversationKey struct {
+	userId    string
+	channelId string
+	private   bool
+}
+
+type session struct {
+	conversation Conversation
+	timer        *time.Timer
+	// generation counts the times the timer was armed, so that a timer firing while being reset is ignored
+	generation uint64
+	// m serializes the messages of a session
+	m *sync.Mutex
+}
+
+type conversationManager struct {
+	m        *sync.Mutex
+	sink     ReplySink
+	sessions map[conversationKey]*session
+}
+
+func keyOf(user *domain.User, channel *domain.Channel, private bool) conversationKey {
+	key := conversationKey{userId: user.Id(), private: private}
+	if !private && channel != nil {
+		key.channelId = channel.Id()
+	}
+	return key
+}
+
+// arm starts or restarts the timeout of a session. The lock must be held
+func (c *conversationManager) arm(key conversationKey, s *session) {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.generation++
+	generation := s.generation
+	s.timer = time.AfterFunc(s.conversation.Timeout, func() {
+		c.expire(key, s, generation)
+	})
+}
+
+// expire ends a session unless it was closed, replaced or used since its timer was armed
+func (c *conversationManager) expire(key conversationKey, s *session, generation uint64) {
+	c.m.Lock()
+	current := c.sessions[key] == s && s.generation == generation
+	if current {
+		delete(c.sessions, key)
+	}
+	c.m.Unlock()
+	if !current || s.conversation.OnTimeout == nil || c.sink == nil {
+		return
+	}
+	for _, message := range s.conversation.OnTimeout() {
+		if err := c.sink.Send(message); err != nil {
+			log.Println("cannot send conversation timeout:", err)
+		}
+	}
+}
+
+func (c *conversationManager) Open(user *domain.User, channel *domain.Channel, private bool, conversation Conversation) error {
+	if user == nil {
+		return fmt.Errorf("cannot open a conversation without user")
+	}
+	if conversation.Handler == nil {
+		return fmt.Errorf("conversation needs a handler")
+	}
+	if conversation.Timeout <= 0 {
+		return fmt.Errorf("conversation needs a timeout")
+	}
+	key := keyOf(user, channel, private)
+	s := &session{conversation: conversation, m: &sync.Mutex{}}
+	c.m.Lock()
+	if previous, ok := c.sessions[key]; ok {
+		previous.timer.Stop()
+	}
+	c.sessions[key] = s
+	c.arm(key, s)
+	c.m.Unlock()
+	return nil
+}
+
+func (c *conversationManager) Close(user *domain.User, channel *domain.Channel, private bool) {
+	key := keyOf(user, channel, private)
+	c.m.Lock()
+	if s, ok := c.sessions[key]; ok {
+		s.timer.Stop()
+		delete(c.sessions, key)
+	}
+	c.m.Unlock()
+}
+
+func (c *conversationManager) Active(user *domain.User, channel *domain.Channel, private bool) bool {
+	c.m.Lock()
+	_, ok := c.sessions[keyOf(user, channel, private)]
+	c.m.Unlock()
+	return ok
+}
+
+func (c *conversationManager) Handle(ctx context.Context, message *domain.ChatMessage) ([]*domain.ClientMessage, bool, error) {
+	if message.Sender() == nil {
+		return nil, false, nil
+	}
+	key := keyOf(message.Sender(), message.Channel(), message.Private())
+	c.m.Lock()
+	s, ok := c.sessions[key]
+	if ok {
+		c.arm(key, s)
+	}
+	c.m.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+	s.m.Lock()
+	replies, done, err := func() (replies []*domain.ClientMessage, done bool, err error) {
+		defer recoverError(&err)
+		return s.conversation.Handler(ctx, message)
+	}()
+	s.m.Unlock()
+	if done {
+		c.m.Lock()
+		if c.sessions[key] == s {
+			s.timer.Stop()
+			delete(c.sessions, key)
+		}
+		c.m.Unlock()
+	}
+	return replies, true, err
+}
+
+// NewConversationManager creates a ConversationManager sending timeout messages through sink, which may be nil
+func NewConversationManager(sink ReplySink) ConversationManager {
+	return &conversationManager{
+		m:        &sync.Mutex{},
+		sink:     sink,
+		sessions: map[conversationKey]*session{},
+	}
+}
+
+type conversationsKey struct{}
+
+func withConversations(ctx context.Context, conversations ConversationManager) context.Context {
+	return context.WithValue(ctx, conversationsKey{}, conversations)
+}
+
+// Conversations returns the ConversationManager of the Invoker executing a command, nil if it has none
+func Conversations(ctx context.Context) ConversationManager {
+	conversations, _ := ctx.Value(conversationsKey{}).(ConversationManager)
+	return conversations
+}
+
+var _ = Conversations
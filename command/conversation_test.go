@@ -0,0 +1,129 @@
+package command
+
+import (
+	"context"
+	"github.com/raf924/connector-sdk/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type deployCommand struct {
+	testCommand
+}
+
+func (d *deployCommand) ExecuteContext(ctx context.Context, command *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	var environment string
+	err := Conversations(ctx).Open(command.Sender(), command.Channel(), command.Private(), Conversation{
+		Timeout: 20 * time.Millisecond,
+		Handler: func(ctx context.Context, message *domain.ChatMessage) ([]*domain.ClientMessage, bool, error) {
+			if len(environment) == 0 {
+				environment = message.Message()
+				return []*domain.ClientMessage{domain.NewClientMessage("confirm deploy to "+environment+"?", message.Sender(), message.Private())}, false, nil
+			}
+			return []*domain.ClientMessage{domain.NewClientMessage("deploying to "+environment, message.Sender(), message.Private())}, true, nil
+		},
+		OnTimeout: func() []*domain.ClientMessage {
+			return []*domain.ClientMessage{domain.NewClientMessage("deploy cancelled", command.Sender(), command.Private())}
+		},
+	})
+	return []*domain.ClientMessage{domain.NewClientMessage("which environment?", command.Sender(), command.Private())}, err
+}
+
+func TestInvoker_Conversation(t *testing.T) {
+	relay := &testDispatcherRelay{}
+	conversations := NewConversationManager(NewReplySink(relay))
+	list := NewCommandList(&deployCommand{testCommand{name: "deploy"}}, &chatCommand{testCommand{name: "logger"}})
+	invoker := NewInvoker(&testExecutor{}, list, WithConversations(conversations))
+	user := domain.NewUser("user", "id", domain.RegularUser)
+	say := func(sender *domain.User, text string) []string {
+		replies, err := invoker.OnChat(context.Background(), domain.NewChatMessage(text, sender, nil, false, false, time.Now(), true))
+		if err != nil {
+			t.Fatalf("unexpected error = %v", err)
+		}
+		var lines []string
+		for _, reply := range replies {
+			lines = append(lines, reply.Message())
+		}
+		return lines
+	}
+
+	message, _ := NewCommandMessage("deploy", "", user, false, time.Now())
+	_, _ = invoker.Execute(context.Background(), message)
+	if got := say(user, "staging"); !reflect.DeepEqual(got, []string{"confirm deploy to staging?"}) {
+		t.Errorf("expected conversation to claim the message got %v", got)
+	}
+	if got := say(domain.NewUser("other", "other", domain.RegularUser), "hello"); !reflect.DeepEqual(got, []string{"logger saw hello"}) {
+		t.Errorf("expected other users to reach interceptors got %v", got)
+	}
+	if got := say(user, "yes"); !reflect.DeepEqual(got, []string{"deploying to staging"}) {
+		t.Errorf("expected conversation to end got %v", got)
+	}
+	if conversations.Active(user, nil, false) {
+		t.Errorf("expected conversation to be closed")
+	}
+
+	_, _ = invoker.Execute(context.Background(), message)
+	time.Sleep(50 * time.Millisecond)
+	if conversations.Active(user, nil, false) {
+		t.Errorf("expected conversation to expire")
+	}
+	if sent := relay.Sent(); !reflect.DeepEqual(sent, []string{"deploy cancelled"}) {
+		t.Errorf("expected timeout message got %v", sent)
+	}
+}
+
+func TestConversationManager_Channels(t *testing.T) {
+	conversations := NewConversationManager(nil)
+	user := domain.NewUser("user", "id", domain.RegularUser)
+	general := domain.NewChannel("c1", "general")
+	err := conversations.Open(user, general, false, Conversation{
+		Timeout: time.Minute,
+		Handler: func(ctx context.Context, message *domain.ChatMessage) ([]*domain.ClientMessage, bool, error) {
+			return nil, true, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if conversations.Active(user, domain.NewChannel("c2", "random"), false) {
+		t.Errorf("expected conversation to be bound to its channel")
+	}
+	_, handled, _ := conversations.Handle(context.Background(), domain.NewChatMessage("hi", user, nil, false, false, time.Now(), true, domain.WithChannel(domain.NewChannel("c2", "random"))))
+	if handled {
+		t.Errorf("expected message from another channel not to be handled")
+	}
+	_, handled, _ = conversations.Handle(context.Background(), domain.NewChatMessage("hi", user, nil, false, false, time.Now(), true, domain.WithChannel(general)))
+	if !handled || conversations.Active(user, general, false) {
+		t.Errorf("expected message from the channel to end the conversation")
+	}
+}
+
+func TestConversationManager_ExpireAfterUse(t *testing.T) {
+	conversations := NewConversationManager(nil).(*conversationManager)
+	user := domain.NewUser("user", "id", domain.RegularUser)
+	timedOut := false
+	err := conversations.Open(user, nil, true, Conversation{
+		Timeout: time.Minute,
+		Handler: func(ctx context.Context, message *domain.ChatMessage) ([]*domain.ClientMessage, bool, error) {
+			return nil, false, nil
+		},
+		OnTimeout: func() []*domain.ClientMessage {
+			timedOut = true
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	key := keyOf(user, nil, true)
+	s := conversations.sessions[key]
+	armed := s.generation
+	_, _, _ = conversations.Handle(context.Background(), domain.NewChatMessage("hi", user, nil, false, true, time.Now(), true))
+	// the timer armed before the message fires while Handle resets it
+	conversations.expire(key, s, armed)
+	if !conversations.Active(user, nil, true) || timedOut {
+		t.Errorf("expected a conversation used after its timer was armed not to expire")
+	}
+	conversations.Close(user, nil, true)
+}
@@ -0,0 +1,127 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"log"
+	"runtime/debug"
+)
+
+// ErrUnknownCommand is returned by Invoker.Execute for messages matching no command.
+// It is not answered since several bots may share a trigger
+var ErrUnknownCommand = errors.New("unknown command")
+
+type ErrorKind int
+
+const (
+	// InternalError is a failure of the command or the bot, its details are not shown to the sender
+	InternalError ErrorKind = iota
+	// UserError is caused by the sender, its message is shown to them
+	UserError
+	// PermissionError means the sender is not allowed to do what they asked for
+	PermissionError
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case UserError:
+		return "user error"
+	case PermissionError:
+		return "permission error"
+	default:
+		return "internal error"
+	}
+}
+
+// Error is the typed error the Invoker turns every command failure into
+type Error struct {
+	Kind ErrorKind
+	Err  error
+	// Stack is set when the error comes from a recovered panic
+	Stack []byte
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NewUserError(format string, a ...interface{}) error {
+	return &Error{Kind: UserError, Err: fmt.Errorf(format, a...)}
+}
+
+func NewPermissionError(format string, a ...interface{}) error {
+	return &Error{Kind: PermissionError, Err: fmt.Errorf(format, a...)}
+}
+
+func NewInternalError(err error) error {
+	return &Error{Kind: InternalError, Err: err}
+}
+
+// AsError converts any error into an *Error. Errors that are not typed are considered internal
+func AsError(err error) *Error {
+	var typedErr *Error
+	if errors.As(err, &typedErr) {
+		return typedErr
+	}
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return &Error{Kind: UserError, Err: err}
+	}
+	return &Error{Kind: InternalError, Err: err}
+}
+
+func panicError(r interface{}) *Error {
+	return &Error{Kind: InternalError, Err: fmt.Errorf("panic: %v", r), Stack: debug.Stack()}
+}
+
+// recoverError turns a panic into an internal *Error stored in err.
+// It must be deferred directly
+func recoverError(err *error) {
+	if r := recover(); r != nil {
+		*err = panicError(r)
+	}
+}
+
+// nameOf returns the Name of cmd, or its type if Name panics like NoOpCommand.Name
+func nameOf(cmd Command) (name string) {
+	defer func() {
+		if r := recover(); r != nil {
+			name = fmt.Sprintf("%T", cmd)
+		}
+	}()
+	return cmd.Name()
+}
+
+// An ErrorReply builds the message sent to the sender of a CommandMessage whose execution failed. It may return nil to stay silent
+type ErrorReply func(message *domain.CommandMessage, err *Error) *domain.ClientMessage
+
+func defaultErrorReplies() map[ErrorKind]ErrorReply {
+	return map[ErrorKind]ErrorReply{
+		InternalError: func(message *domain.CommandMessage, _ *Error) *domain.ClientMessage {
+			return message.Reply(fmt.Sprintf("Something went wrong while executing %s", message.Command()))
+		},
+		UserError: func(message *domain.CommandMessage, err *Error) *domain.ClientMessage {
+			return message.Reply(err.Error())
+		},
+		PermissionError: func(message *domain.CommandMessage, _ *Error) *domain.ClientMessage {
+			return PermissionDeniedReply(message)
+		},
+	}
+}
+
+func logError(ctx context.Context, source string, err *Error) {
+	if err.Kind != InternalError {
+		return
+	}
+	if err.Stack != nil {
+		log.Printf("[%s] %s: %v\n%s", CorrelationId(ctx), source, err, err.Stack)
+		return
+	}
+	log.Printf("[%s] %s: %v", CorrelationId(ctx), source, err)
+}
@@ -0,0 +1,153 @@
+package command
+
+import (
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+)
+
+var _ ArgumentsExecutable = (*helpCommand)(nil)
+
+type helpCommand struct {
+	NoOpCommand
+	bot      Executor
+	commands List
+	pageSize int
+}
+
+func (h *helpCommand) Init(bot Executor) error {
+	h.bot = bot
+	return nil
+}
+
+func (h *helpCommand) Name() string {
+	return "help"
+}
+
+func (h *helpCommand) Usage() string {
+	return Usage(h.Name(), h.Schema())
+}
+
+func (h *helpCommand) Schema() *Schema {
+	return &Schema{
+		Arguments: []Argument{{Name: "command", Variadic: true}},
+		Flags:     []Flag{{Name: "page", Type: IntArgument, Default: 1}},
+	}
+}
+
+func (h *helpCommand) Execute(command *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	arguments, err := ParseArguments(h.Schema(), command)
+	if err != nil {
+		return nil, err
+	}
+	return h.ExecuteArguments(command, arguments)
+}
+
+func (h *helpCommand) ExecuteArguments(command *domain.CommandMessage, arguments *Arguments) ([]*domain.ClientMessage, error) {
+	if h.bot == nil {
+		return nil, NewInternalError(fmt.Errorf("help command was not initialized"))
+	}
+	var lines []string
+	path := arguments.Strings("command")
+	if len(path) == 0 {
+		lines = h.list(command.Sender())
+	} else {
+		var err error
+		lines, err = h.describe(command.Sender(), path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h.paginate(command, strings.Join(append([]string{command.Command()}, path...), " "), lines, arguments.Int("page"))
+}
+
+func (h *helpCommand) describeLine(path string, cmd Command) string {
+	line := h.bot.Trigger() + path
+	if usage := describe(path, cmd).Usage(); len(usage) > 0 {
+		line = h.bot.Trigger() + usage
+	}
+	return line
+}
+
+func (h *helpCommand) list(user *domain.User) []string {
+	var lines []string
+	h.commands.Range(func(cmd Command) bool {
+		if hasPermission(h.bot, user, []Command{cmd}) {
+			lines = append(lines, h.describeLine(cmd.Name(), cmd))
+		}
+		return true
+	})
+	return lines
+}
+
+func (h *helpCommand) describe(user *domain.User, path []string) ([]string, error) {
+	cmd := h.commands.Find(path[0])
+	var chain []Command
+	if cmd != nil {
+		chain = resolve(cmd, path[1:])
+	}
+	// commands the user cannot use are reported as unknown
+	if len(chain) != len(path) || !hasPermission(h.bot, user, chain) {
+		return nil, NewUserError("unknown command %s", strings.Join(path, " "))
+	}
+	cmd = chain[len(chain)-1]
+	names := make([]string, len(chain))
+	for i, c := range chain {
+		names[i] = c.Name()
+	}
+	fullPath := strings.Join(names, " ")
+	lines := []string{h.describeLine(fullPath, cmd)}
+	if aliases := cmd.Aliases(); len(aliases) > 0 {
+		lines = append(lines, "Aliases: "+strings.Join(aliases, ", "))
+	}
+	if parent, ok := cmd.(Parent); ok {
+		for _, sub := range parent.Subcommands() {
+			if hasPermission(h.bot, user, []Command{sub}) {
+				lines = append(lines, "  "+h.describeLine(fullPath+" "+sub.Name(), sub))
+			}
+		}
+	}
+	return lines, nil
+}
+
+func (h *helpCommand) paginate(command *domain.CommandMessage, invocation string, lines []string, page int) ([]*domain.ClientMessage, error) {
+	pageCount := (len(lines) + h.pageSize - 1) / h.pageSize
+	if pageCount == 0 {
+		return []*domain.ClientMessage{command.Reply("No command available")}, nil
+	}
+	if page < 1 || page > pageCount {
+		return nil, NewUserError("page must be between 1 and %d", pageCount)
+	}
+	start := (page - 1) * h.pageSize
+	end := start + h.pageSize
+	if end > len(lines) {
+		end = len(lines)
+	}
+	messages := make([]*domain.ClientMessage, 0, end-start+1)
+	for _, line := range lines[start:end] {
+		messages = append(messages, command.Reply(line))
+	}
+	if pageCount > 1 {
+		footer := fmt.Sprintf("Page %d/%d", page, pageCount)
+		if page < pageCount {
+			footer += fmt.Sprintf(", use %s%s --page=%d for more", h.bot.Trigger(), invocation, page+1)
+		}
+		messages = append(messages, command.Reply(footer))
+	}
+	return messages, nil
+}
+
+// NewHelpCommand creates an opt-in help command listing the commands of the list the caller is allowed to use.
+// `help <command> [subcommand...]` describes a single command. Output longer than pageSize lines is split into pages.
+// It needs the bot to check permissions, so Init must be called before it executes, e.g. with InitCommand
+func NewHelpCommand(commands List, pageSize int) Command {
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	return &helpCommand{
+		commands: commands,
+		pageSize: pageSize,
+	}
+}
+
+var _ = NewHelpCommand
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"context"
+	"github.com/raf924/connector-sdk/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func helpMessages(t *testing.T, invoker Invoker, userId string, argString string) []string {
+	message, _ := NewCommandMessage("help", argString, domain.NewUser(userId, userId, domain.RegularUser), false, time.Now())
+	replies, err := invoker.Execute(context.Background(), message)
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	lines := make([]string, len(replies))
+	for i, reply := range replies {
+		lines[i] = reply.Message()
+	}
+	return lines
+}
+
+func TestHelpCommand(t *testing.T) {
+	bot := &testExecutor{permissions: map[string]domain.Permission{"mod": domain.IsModerator}}
+	list := NewCommandList(quoteCommand, &restrictedCommand{testCommand: testCommand{name: "ban"}, permission: domain.NeedModerator})
+	help := NewHelpCommand(list, 2)
+	if err := list.Add(help); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	invoker := NewInvoker(bot, list)
+	message, _ := NewCommandMessage("help", "", domain.NewUser("user", "user", domain.RegularUser), false, time.Now())
+	if _, err := help.(ArgumentsExecutable).ExecuteArguments(message, newArguments()); err == nil || AsError(err).Kind != InternalError {
+		t.Errorf("expected an uninitialized help command to fail got %v", err)
+	}
+	if err := InitCommand(bot, help); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+
+	lines := helpMessages(t, invoker, "user", "")
+	if !reflect.DeepEqual(lines, []string{"!quote", "!help [command...] [--page=<int>]"}) {
+		t.Errorf("expected ban to be hidden got %q", lines)
+	}
+	lines = helpMessages(t, invoker, "mod", "")
+	if !reflect.DeepEqual(lines, []string{"!quote", "!ban", "Page 1/2, use !help --page=2 for more"}) {
+		t.Errorf("expected paginated help got %q", lines)
+	}
+	lines = helpMessages(t, invoker, "mod", "--page=2")
+	if !reflect.DeepEqual(lines, []string{"!help [command...] [--page=<int>]", "Page 2/2"}) {
+		t.Errorf("unexpected second page %q", lines)
+	}
+	lines = helpMessages(t, invoker, "user", "quote new")
+	if !reflect.DeepEqual(lines, []string{"!quote add", "Aliases: new"}) {
+		t.Errorf("unexpected subcommand help %q", lines)
+	}
+	lines = helpMessages(t, invoker, "user", "ban")
+	if !reflect.DeepEqual(lines, []string{"unknown command ban"}) {
+		t.Errorf("expected ban to be unknown got %q", lines)
+	}
+}
@@ -0,0 +1,419 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"log"
+	"sync"
+	"time"
+)
+
+// An Invoker runs the commands of a List on behalf of the bot.
+// Panics are recovered and errors are converted into an *Error.
+// Commands receive a context carrying a correlation id, cancelled when they time out, when ctx is done or when the Invoker shuts down
+type Invoker interface {
+	// Execute finds the command targeted by the CommandMessage and executes it.
+	// A failed execution is logged and answered with the ErrorReply matching the kind of the error instead of returning it.
+	// A message matching no command is not answered, ErrUnknownCommand is returned instead
+	Execute(ctx context.Context, message *domain.CommandMessage) ([]*domain.ClientMessage, error)
+	// OnChat passes a ChatMessage to every command of the list.
+	// Every command is called even if some fail, the first error is returned along with all the replies.
+	// A message from a user with a pending conversation is only passed to the conversation
+	OnChat(ctx context.Context, message *domain.ChatMessage) ([]*domain.ClientMessage, error)
+	// OnUserEvent passes a UserEvent to every command of the list like OnChat
+	OnUserEvent(ctx context.Context, event *domain.UserEvent) ([]*domain.ClientMessage, error)
+	// Shutdown stops accepting messages, waits for in-flight executions until ctx is done, cancels those still running
+	// and waits for them to return, then shuts down the commands of the list in reverse order.
+	// Cancelled executions and command shutdowns are each given the shutdown timeout of the Invoker.
+	// Once called, the other methods return ErrShuttingDown
+	Shutdown(ctx context.Context) error
+}
+
+type InvokerOption func(invoker *invoker)
+
+// WithRateLimiter makes the Invoker enforce the rate limits attached to commands
+func WithRateLimiter(rateLimiter RateLimiter) InvokerOption {
+	return func(invoker *invoker) {
+		invoker.rateLimiter = rateLimiter
+	}
+}
+
+// WithErrorReply replaces the reply sent for a kind of error
+func WithErrorReply(kind ErrorKind, reply ErrorReply) InvokerOption {
+	return func(invoker *invoker) {
+		invoker.errorReplies[kind] = reply
+	}
+}
+
+// WithMiddlewares appends middlewares to the Invoker. The first middleware is the outermost one
+func WithMiddlewares(middlewares ...Middleware) InvokerOption {
+	return func(invoker *invoker) {
+		invoker.middlewares = append(invoker.middlewares, middlewares...)
+	}
+}
+
+// WithReplySink makes sink available to commands through Replies. The sink is closed when the Invoker shuts down
+func WithReplySink(sink ReplySink) InvokerOption {
+	return func(invoker *invoker) {
+		invoker.replySink = sink
+	}
+}
+
+// WithConversations routes the chat messages of users with a pending conversation to their conversation
+// and makes conversations available to commands through Conversations
+func WithConversations(conversations ConversationManager) InvokerOption {
+	return func(invoker *invoker) {
+		invoker.conversations = conversations
+	}
+}
+
+// WithTimeout sets how long a command may run before its context is cancelled, unless it implements TimeLimited.
+// Commands are not limited by default
+func WithTimeout(timeout time.Duration) InvokerOption {
+	return func(invoker *invoker) {
+		invoker.timeout = timeout
+	}
+}
+
+// WithShutdownTimeout sets how long Shutdown waits for cancelled executions to return,
+// and the deadline given to the commands to shut down. It defaults to 5 seconds
+func WithShutdownTimeout(timeout time.Duration) InvokerOption {
+	return func(invoker *invoker) {
+		invoker.shutdownTimeout = timeout
+	}
+}
+
+type invoker struct {
+	bot             Executor
+	commands        List
+	rateLimiter     RateLimiter
+	middlewares     []Middleware
+	errorReplies    map[ErrorKind]ErrorReply
+	timeout         time.Duration
+	shutdownTimeout time.Duration
+	replySink       ReplySink
+	conversations   ConversationManager
+	onChat          ChatHandler
+	onUserEvent     UserEventHandler
+	m               *sync.Mutex
+	// closing is closed once Shutdown is called
+	closing  chan struct{}
+	inFlight *sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+var _ Invoker = (*invoker)(nil)
+
+// isClosing reports whether Shutdown was called. The lock must be held
+func (i *invoker) isClosing() bool {
+	select {
+	case <-i.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// begin registers an in-flight execution and derives its context.
+// It returns a nil context if the invoker is shutting down
+func (i *invoker) begin(ctx context.Context) (context.Context, context.CancelFunc) {
+	i.m.Lock()
+	closing := i.isClosing()
+	if !closing {
+		i.inFlight.Add(1)
+	}
+	i.m.Unlock()
+	if closing {
+		return nil, nil
+	}
+	ctx = ensureCorrelationId(ctx)
+	if i.replySink != nil {
+		ctx = withReplySink(ctx, i.replySink)
+	}
+	if i.conversations != nil {
+		ctx = withConversations(ctx, i.conversations)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-i.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		cancel()
+		i.inFlight.Done()
+	}
+}
+
+func (i *invoker) withTimeout(ctx context.Context, cmd Command) (context.Context, context.CancelFunc) {
+	timeout := i.timeout
+	if timeLimited, ok := cmd.(TimeLimited); ok {
+		timeout = timeLimited.Timeout()
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// track registers the execution of chain with the list so that Reload and Unload wait for it before shutting its command down.
+// A chain whose command was replaced or removed since it was resolved is resolved again
+func (i *invoker) track(chain []Command, message *domain.CommandMessage) ([]Command, *domain.CommandMessage, func(), error) {
+	tracker, ok := i.commands.(executionTracker)
+	if !ok {
+		return chain, message, func() {}, nil
+	}
+	if done, ok := tracker.track(chain[0]); ok {
+		return chain, message, done, nil
+	}
+	chain, message = resolveMessage(i.commands, message)
+	if chain != nil {
+		if done, ok := tracker.track(chain[0]); ok {
+			return chain, message, done, nil
+		}
+	}
+	return nil, message, nil, fmt.Errorf("%w %s", ErrUnknownCommand, message.Command())
+}
+
+func (i *invoker) run(ctx context.Context, chain []Command, message *domain.CommandMessage) (replies []*domain.ClientMessage, err error) {
+	defer recoverError(&err)
+	if !hasPermission(i.bot, message.Sender(), chain) {
+		return nil, NewPermissionError("missing permission to use %s", message.Command())
+	}
+	if i.rateLimiter != nil {
+		if wait, ok := i.rateLimiter.Check(chain, message); !ok {
+			return []*domain.ClientMessage{CooldownReply(message, wait)}, nil
+		}
+	}
+	cmd := chain[len(chain)-1]
+	ctx, cancel := i.withTimeout(ctx, cmd)
+	defer cancel()
+	var schema *Schema
+	switch executable := cmd.(type) {
+	case ContextArgumentsExecutable:
+		schema = executable.Schema()
+	case ArgumentsExecutable:
+		schema = executable.Schema()
+	default:
+		return executeContext(ctx, cmd, message)
+	}
+	arguments, err := ParseArguments(schema, message)
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return []*domain.ClientMessage{UsageReply(i.bot.Trigger(), message, schema, err)}, nil
+	}
+	if err != nil {
+		return nil, NewInternalError(err)
+	}
+	if executable, ok := cmd.(ContextArgumentsExecutable); ok {
+		return executable.ExecuteArgumentsContext(ctx, message, arguments)
+	}
+	return cmd.(ArgumentsExecutable).ExecuteArguments(message, arguments)
+}
+
+func (i *invoker) replyError(ctx context.Context, message *domain.CommandMessage, err error) []*domain.ClientMessage {
+	typedErr := AsError(err)
+	logError(ctx, message.Command(), typedErr)
+	reply, ok := i.errorReplies[typedErr.Kind]
+	if !ok {
+		return nil
+	}
+	if clientMessage := reply(message, typedErr); clientMessage != nil {
+		return []*domain.ClientMessage{clientMessage}
+	}
+	return nil
+}
+
+func (i *invoker) Execute(ctx context.Context, message *domain.CommandMessage) (replies []*domain.ClientMessage, err error) {
+	ctx, done := i.begin(ctx)
+	if ctx == nil {
+		return nil, ErrShuttingDown
+	}
+	defer done()
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+		if err != nil && !errors.Is(err, ErrUnknownCommand) {
+			replies = append(replies, i.replyError(ctx, message, err)...)
+			err = nil
+		}
+	}()
+	chain, message := resolveMessage(i.commands, message)
+	if chain == nil {
+		return nil, fmt.Errorf("%w %s", ErrUnknownCommand, message.Command())
+	}
+	chain, message, untrack, err := i.track(chain, message)
+	if err != nil {
+		return nil, err
+	}
+	defer untrack()
+	path := message.Command()
+	handler := wrapExecute(i.middlewares, func(ctx context.Context, cmd Command, message *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+		if message.Command() != path {
+			chain, message := resolveMessage(i.commands, message)
+			if chain == nil {
+				return nil, fmt.Errorf("%w %s", ErrUnknownCommand, message.Command())
+			}
+			chain, message, untrack, err := i.track(chain, message)
+			if err != nil {
+				return nil, err
+			}
+			defer untrack()
+			return i.run(ctx, chain, message)
+		}
+		return i.run(ctx, append(chain[:len(chain)-1:len(chain)-1], cmd), message)
+	})
+	return handler(ctx, chain[len(chain)-1], message)
+}
+
+func (i *invoker) isSelf(user *domain.User) bool {
+	botUser := i.bot.BotUser()
+	return user != nil && botUser != nil && user.Is(botUser)
+}
+
+func (i *invoker) intercept(ctx context.Context, self bool, handle func(ctx context.Context, cmd Command) ([]*domain.ClientMessage, error)) ([]*domain.ClientMessage, error) {
+	ctx, done := i.begin(ctx)
+	if ctx == nil {
+		return nil, ErrShuttingDown
+	}
+	defer done()
+	var replies []*domain.ClientMessage
+	var firstErr error
+	i.commands.Range(func(cmd Command) bool {
+		messages, err := func() (replies []*domain.ClientMessage, err error) {
+			defer recoverError(&err)
+			if self && cmd.IgnoreSelf() {
+				return nil, nil
+			}
+			if tracker, ok := i.commands.(executionTracker); ok {
+				done, ok := tracker.track(cmd)
+				if !ok {
+					return nil, nil
+				}
+				defer done()
+			}
+			ctx, cancel := i.withTimeout(ctx, cmd)
+			defer cancel()
+			return handle(ctx, cmd)
+		}()
+		if err != nil {
+			typedErr := AsError(err)
+			logError(ctx, nameOf(cmd), typedErr)
+			if firstErr == nil {
+				firstErr = typedErr
+			}
+		}
+		replies = append(replies, messages...)
+		return true
+	})
+	return replies, firstErr
+}
+
+func (i *invoker) OnChat(ctx context.Context, message *domain.ChatMessage) ([]*domain.ClientMessage, error) {
+	if i.conversations != nil && !i.isSelf(message.Sender()) {
+		ctx, done := i.begin(ctx)
+		if ctx == nil {
+			return nil, ErrShuttingDown
+		}
+		replies, handled, err := i.conversations.Handle(ctx, message)
+		done()
+		if handled {
+			if err != nil {
+				typedErr := AsError(err)
+				logError(ctx, "conversation", typedErr)
+				err = typedErr
+			}
+			return replies, err
+		}
+	}
+	return i.intercept(ctx, i.isSelf(message.Sender()), func(ctx context.Context, cmd Command) ([]*domain.ClientMessage, error) {
+		return i.onChat(ctx, cmd, message)
+	})
+}
+
+func (i *invoker) OnUserEvent(ctx context.Context, event *domain.UserEvent) ([]*domain.ClientMessage, error) {
+	return i.intercept(ctx, i.isSelf(event.User()), func(ctx context.Context, cmd Command) ([]*domain.ClientMessage, error) {
+		return i.onUserEvent(ctx, cmd, event)
+	})
+}
+
+func (i *invoker) Shutdown(ctx context.Context) error {
+	i.m.Lock()
+	closing := i.isClosing()
+	if !closing {
+		close(i.closing)
+	}
+	i.m.Unlock()
+	if closing {
+		return ErrShuttingDown
+	}
+	drained := make(chan struct{})
+	go func() {
+		i.inFlight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		log.Println("cancelling in-flight executions:", ctx.Err())
+		i.cancel()
+		select {
+		case <-drained:
+		case <-time.After(i.shutdownTimeout):
+			log.Println("in-flight executions did not return after being cancelled")
+		}
+	}
+	i.cancel()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), i.shutdownTimeout)
+	defer cancel()
+	var commands []Command
+	i.commands.Range(func(cmd Command) bool {
+		commands = append(commands, cmd)
+		return true
+	})
+	var firstErr error
+	for j := len(commands) - 1; j >= 0; j-- {
+		if err := ShutdownCommand(shutdownCtx, commands[j]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if i.replySink != nil {
+		i.replySink.Close()
+	}
+	return firstErr
+}
+
+func NewInvoker(bot Executor, commands List, options ...InvokerOption) Invoker {
+	ctx, cancel := context.WithCancel(context.Background())
+	i := &invoker{
+		bot:             bot,
+		commands:        commands,
+		errorReplies:    defaultErrorReplies(),
+		shutdownTimeout: 5 * time.Second,
+		m:               &sync.Mutex{},
+		closing:         make(chan struct{}),
+		inFlight:        &sync.WaitGroup{},
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	for _, option := range options {
+		option(i)
+	}
+	i.onChat = wrapChat(i.middlewares, func(ctx context.Context, cmd Command, message *domain.ChatMessage) (replies []*domain.ClientMessage, err error) {
+		defer recoverError(&err)
+		return onChatContext(ctx, cmd, message)
+	})
+	i.onUserEvent = wrapUserEvent(i.middlewares, func(ctx context.Context, cmd Command, event *domain.UserEvent) (replies []*domain.ClientMessage, err error) {
+		defer recoverError(&err)
+		return onUserEventContext(ctx, cmd, event)
+	})
+	return i
+}
+
+var _ = NewInvoker
@@ -0,0 +1,166 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/raf924/connector-sdk/domain"
+	"testing"
+	"time"
+)
+
+type testExecutor struct {
+	permissions map[string]domain.Permission
+}
+
+func (t *testExecutor) BotUser() *domain.User {
+	return domain.NewUser("bot", "bot", domain.RegularUser)
+}
+
+func (t *testExecutor) ApiKeys() map[string]string {
+	return map[string]string{}
+}
+
+func (t *testExecutor) OnlineUsers() domain.UserList {
+	return domain.NewUserList()
+}
+
+func (t *testExecutor) UserHasPermission(user *domain.User, permission domain.Permission) bool {
+	return t.permissions[user.Id()].Has(permission)
+}
+
+func (t *testExecutor) Trigger() string {
+	return "!"
+}
+
+type restrictedCommand struct {
+	testCommand
+	permission domain.Permission
+}
+
+func (r *restrictedCommand) Permission() domain.Permission {
+	return r.permission
+}
+
+func TestInvoker_Permission(t *testing.T) {
+	ban := &restrictedCommand{testCommand: testCommand{name: "ban"}, permission: domain.NeedModerator}
+	admin := &restrictedCommand{testCommand: testCommand{name: "admin", subcommands: []Command{ban}}, permission: domain.NeedVerified}
+	bot := &testExecutor{permissions: map[string]domain.Permission{"mod": domain.IsModerator, "verified": domain.IsVerified}}
+	invoker := NewInvoker(bot, NewCommandList(admin))
+	tests := []struct {
+		name    string
+		userId  string
+		allowed bool
+	}{
+		{name: "moderator can ban", userId: "mod", allowed: true},
+		{name: "verified cannot ban", userId: "verified", allowed: false},
+		{name: "unknown cannot use admin", userId: "unknown", allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, _ := NewCommandMessage("admin", "ban someone", domain.NewUser(tt.userId, tt.userId, domain.RegularUser), false, time.Now())
+			replies, err := invoker.Execute(context.Background(), message)
+			if err != nil {
+				t.Fatalf("unexpected error = %v", err)
+			}
+			if len(replies) != 1 {
+				t.Fatalf("expected %v reply got %v", 1, len(replies))
+			}
+			if executed := replies[0].Message() == "admin ban:someone"; executed != tt.allowed {
+				t.Errorf("expected allowed = %v got %v", tt.allowed, replies[0].Message())
+			}
+		})
+	}
+}
+
+type failingCommand struct {
+	testCommand
+	err error
+}
+
+func (f *failingCommand) Execute(*domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	if f.err == nil {
+		panic("implement me")
+	}
+	return nil, f.err
+}
+
+type invalidSchemaCommand struct {
+	testCommand
+}
+
+func (i *invalidSchemaCommand) Schema() *Schema {
+	return &Schema{Flags: []Flag{{Name: "ratio", Type: FloatArgument, Default: 1}}}
+}
+
+func (i *invalidSchemaCommand) ExecuteArguments(*domain.CommandMessage, *Arguments) ([]*domain.ClientMessage, error) {
+	return nil, nil
+}
+
+func TestInvoker_Errors(t *testing.T) {
+	list := NewCommandList(
+		&invalidSchemaCommand{testCommand: testCommand{name: "schema"}},
+		&failingCommand{testCommand: testCommand{name: "panics"}},
+		&failingCommand{testCommand: testCommand{name: "internal"}, err: errors.New("database is down")},
+		&failingCommand{testCommand: testCommand{name: "user"}, err: NewUserError("city not found")},
+	)
+	internalReply := func(message *domain.CommandMessage, err *Error) *domain.ClientMessage {
+		return domain.NewClientMessage("oops: "+message.Command(), message.Sender(), message.Private())
+	}
+	invoker := NewInvoker(&testExecutor{}, list, WithErrorReply(InternalError, internalReply))
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "panics", want: "oops: panics"},
+		{command: "internal", want: "oops: internal"},
+		{command: "user", want: "city not found"},
+		{command: "schema", want: "oops: schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			message, _ := NewCommandMessage(tt.command, "", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+			replies, err := invoker.Execute(context.Background(), message)
+			if err != nil {
+				t.Fatalf("unexpected error = %v", err)
+			}
+			if len(replies) != 1 || replies[0].Message() != tt.want {
+				t.Errorf("expected %v got %v", tt.want, replies)
+			}
+		})
+	}
+}
+
+func TestInvoker_UnknownCommand(t *testing.T) {
+	invoker := NewInvoker(&testExecutor{}, NewCommandList())
+	message, _ := NewCommandMessage("unknown", "", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	replies, err := invoker.Execute(context.Background(), message)
+	if !errors.Is(err, ErrUnknownCommand) || len(replies) != 0 {
+		t.Errorf("expected %v without replies got %v, %v", ErrUnknownCommand, err, replies)
+	}
+}
+
+type unnamedCommand struct {
+	NoOpCommand
+}
+
+type carelessCommand struct {
+	testCommand
+}
+
+func (c *carelessCommand) IgnoreSelf() bool {
+	panic("implement me")
+}
+
+func TestInvoker_PanickingCommandMethods(t *testing.T) {
+	if err := HandleCommand(&unnamedCommand{}); err == nil {
+		t.Errorf("expected a command without name to be rejected")
+	}
+	if err := NewCommandList().Add(&unnamedCommand{}); err == nil {
+		t.Errorf("expected a command without name to be rejected")
+	}
+	bot := &testExecutor{}
+	invoker := NewInvoker(bot, NewCommandList(&carelessCommand{testCommand{name: "careless"}}))
+	if _, err := invoker.OnChat(context.Background(), domain.NewChatMessage("hi", bot.BotUser(), nil, false, false, time.Now(), true)); err == nil {
+		t.Errorf("expected the panic to be returned as an error")
+	}
+}
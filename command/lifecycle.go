@@ -0,0 +1,58 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/raf924/connector-sdk/rpc"
+	"log"
+	"time"
+)
+
+// ErrShuttingDown is returned by an Invoker asked to run a command after Shutdown was called
+var ErrShuttingDown = errors.New("invoker is shutting down")
+
+// Stoppable should be implemented by commands holding resources such as timers, goroutines or connections.
+// Shutdown is called once the bot stops, in the reverse order of registration, after in-flight executions are drained
+type Stoppable interface {
+	Shutdown(ctx context.Context) error
+}
+
+// ShutdownCommand shuts down the subcommands of a command, in reverse order, then the command itself.
+// Every Stoppable is called, the first error is returned
+func ShutdownCommand(ctx context.Context, command Command) error {
+	var firstErr error
+	if parent, ok := command.(Parent); ok {
+		subcommands := parent.Subcommands()
+		for i := len(subcommands) - 1; i >= 0; i-- {
+			if err := ShutdownCommand(ctx, subcommands[i]); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if stoppable, ok := command.(Stoppable); ok {
+		if err := stoppable.Shutdown(ctx); err != nil {
+			log.Printf("%s: shutdown failed: %v", nameOf(command), err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// ShutdownOnDone shuts the invoker down once the relay is done, giving in-flight executions up to timeout to return.
+// Executions still running are then cancelled, so the shutdown may last up to timeout plus twice the shutdown timeout
+// of the invoker, which bounds both the wait for cancelled executions and the command shutdowns.
+// The returned channel receives the result of the shutdown
+func ShutdownOnDone(relay rpc.DispatcherRelay, invoker Invoker, timeout time.Duration) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		<-relay.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		result <- invoker.Shutdown(ctx)
+		cancel()
+	}()
+	return result
+}
+
+var _ = ShutdownOnDone
@@ -0,0 +1,122 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type stoppableCommand struct {
+	testCommand
+	started  chan struct{}
+	release  chan struct{}
+	finished bool
+	stopped  *[]string
+}
+
+func (s *stoppableCommand) Execute(*domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	if s.started != nil {
+		close(s.started)
+		<-s.release
+	}
+	s.finished = true
+	return nil, nil
+}
+
+func (s *stoppableCommand) Shutdown(context.Context) error {
+	*s.stopped = append(*s.stopped, s.name)
+	return nil
+}
+
+// closingOf returns the channel closed once Shutdown is called on i
+func closingOf(i Invoker) <-chan struct{} {
+	return i.(*invoker).closing
+}
+
+func TestInvoker_Shutdown(t *testing.T) {
+	var stopped []string
+	slow := &stoppableCommand{testCommand: testCommand{name: "slow"}, started: make(chan struct{}), release: make(chan struct{}), stopped: &stopped}
+	child := &stoppableCommand{testCommand: testCommand{name: "child"}, stopped: &stopped}
+	parent := &stoppableCommand{testCommand: testCommand{name: "parent", subcommands: []Command{child}}, stopped: &stopped}
+	invoker := NewInvoker(&testExecutor{}, NewCommandList(slow, parent))
+	relay := &testDispatcherRelay{done: make(chan struct{})}
+	result := ShutdownOnDone(relay, invoker, time.Second)
+
+	message, _ := NewCommandMessage("slow", "", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	go func() {
+		_, _ = invoker.Execute(context.Background(), message)
+	}()
+	<-slow.started
+	close(relay.done)
+	<-closingOf(invoker)
+	if _, err := invoker.Execute(context.Background(), message); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected %v got %v", ErrShuttingDown, err)
+	}
+	if len(stopped) != 0 {
+		t.Errorf("expected shutdown to wait for in-flight executions got %v", stopped)
+	}
+	close(slow.release)
+	if err := <-result; err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if !slow.finished {
+		t.Errorf("expected in-flight execution to finish")
+	}
+	if strings.Join(stopped, ",") != "child,parent,slow" {
+		t.Errorf("expected commands to be shut down in reverse order got %v", stopped)
+	}
+}
+
+type cancellableCommand struct {
+	testCommand
+	started       chan struct{}
+	m             sync.Mutex
+	finished      bool
+	finishedFirst bool
+	shutdownErr   error
+}
+
+func (c *cancellableCommand) ExecuteContext(ctx context.Context, _ *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	close(c.started)
+	<-ctx.Done()
+	time.Sleep(20 * time.Millisecond)
+	c.m.Lock()
+	c.finished = true
+	c.m.Unlock()
+	return nil, ctx.Err()
+}
+
+func (c *cancellableCommand) Shutdown(ctx context.Context) error {
+	c.m.Lock()
+	c.finishedFirst = c.finished
+	c.shutdownErr = ctx.Err()
+	c.m.Unlock()
+	return nil
+}
+
+func TestInvoker_ShutdownAfterCancel(t *testing.T) {
+	cmd := &cancellableCommand{testCommand: testCommand{name: "slow"}, started: make(chan struct{})}
+	invoker := NewInvoker(&testExecutor{}, NewCommandList(cmd), WithShutdownTimeout(time.Second))
+	message, _ := NewCommandMessage("slow", "", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	go func() {
+		_, _ = invoker.Execute(context.Background(), message)
+	}()
+	<-cmd.started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := invoker.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	cmd.m.Lock()
+	defer cmd.m.Unlock()
+	if !cmd.finishedFirst {
+		t.Errorf("expected shutdown to wait for the cancelled execution to return")
+	}
+	if cmd.shutdownErr != nil {
+		t.Errorf("expected command shutdown to get its own deadline got %v", cmd.shutdownErr)
+	}
+}
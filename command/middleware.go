@@ -0,0 +1,111 @@
+package command
+
+import (
+	"context"
+	"github.com/raf924/connector-sdk/domain"
+)
+
+type ExecuteHandler func(ctx context.Context, command Command, message *domain.CommandMessage) ([]*domain.ClientMessage, error)
+
+type ChatHandler func(ctx context.Context, command Command, message *domain.ChatMessage) ([]*domain.ClientMessage, error)
+
+type UserEventHandler func(ctx context.Context, command Command, event *domain.UserEvent) ([]*domain.ClientMessage, error)
+
+// A Middleware wraps the handlers used by an Invoker to call Execute, OnChat and OnUserEvent.
+// A wrapping handler can short-circuit by not calling next, call next with a different message or context
+// or transform the messages returned by next.
+// The innermost Execute handler runs the command it is given, with the permissions and rate limits of its parents.
+// A message whose Command was rewritten is resolved again instead, running the command of the list it now targets
+type Middleware interface {
+	Execute(next ExecuteHandler) ExecuteHandler
+	OnChat(next ChatHandler) ChatHandler
+	OnUserEvent(next UserEventHandler) UserEventHandler
+}
+
+// NoOpMiddleware should be embedded by middlewares that only need to wrap some of the handlers
+type NoOpMiddleware struct {
+}
+
+func (n *NoOpMiddleware) Execute(next ExecuteHandler) ExecuteHandler {
+	return next
+}
+
+func (n *NoOpMiddleware) OnChat(next ChatHandler) ChatHandler {
+	return next
+}
+
+func (n *NoOpMiddleware) OnUserEvent(next UserEventHandler) UserEventHandler {
+	return next
+}
+
+// ExecuteMiddleware is a Middleware that only wraps Execute
+type ExecuteMiddleware func(next ExecuteHandler) ExecuteHandler
+
+func (e ExecuteMiddleware) Execute(next ExecuteHandler) ExecuteHandler {
+	return e(next)
+}
+
+func (e ExecuteMiddleware) OnChat(next ChatHandler) ChatHandler {
+	return next
+}
+
+func (e ExecuteMiddleware) OnUserEvent(next UserEventHandler) UserEventHandler {
+	return next
+}
+
+// ChatMiddleware is a Middleware that only wraps OnChat
+type ChatMiddleware func(next ChatHandler) ChatHandler
+
+func (c ChatMiddleware) Execute(next ExecuteHandler) ExecuteHandler {
+	return next
+}
+
+func (c ChatMiddleware) OnChat(next ChatHandler) ChatHandler {
+	return c(next)
+}
+
+func (c ChatMiddleware) OnUserEvent(next UserEventHandler) UserEventHandler {
+	return next
+}
+
+// UserEventMiddleware is a Middleware that only wraps OnUserEvent
+type UserEventMiddleware func(next UserEventHandler) UserEventHandler
+
+func (u UserEventMiddleware) Execute(next ExecuteHandler) ExecuteHandler {
+	return next
+}
+
+func (u UserEventMiddleware) OnChat(next ChatHandler) ChatHandler {
+	return next
+}
+
+func (u UserEventMiddleware) OnUserEvent(next UserEventHandler) UserEventHandler {
+	return u(next)
+}
+
+var _ Middleware = (*NoOpMiddleware)(nil)
+var _ Middleware = ExecuteMiddleware(nil)
+var _ Middleware = ChatMiddleware(nil)
+var _ Middleware = UserEventMiddleware(nil)
+
+// the first middleware is the outermost one
+func wrapExecute(middlewares []Middleware, handler ExecuteHandler) ExecuteHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i].Execute(handler)
+	}
+	return handler
+}
+
+func wrapChat(middlewares []Middleware, handler ChatHandler) ChatHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i].OnChat(handler)
+	}
+	return handler
+}
+
+func wrapUserEvent(middlewares []Middleware, handler UserEventHandler) UserEventHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i].OnUserEvent(handler)
+	}
+	return handler
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"context"
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+type chatCommand struct {
+	testCommand
+}
+
+func (c *chatCommand) OnChat(message *domain.ChatMessage) ([]*domain.ClientMessage, error) {
+	return []*domain.ClientMessage{domain.NewClientMessage(c.name+" saw "+message.Message(), nil, false)}, nil
+}
+
+func TestInvoker_Middlewares(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return ExecuteMiddleware(func(next ExecuteHandler) ExecuteHandler {
+			return func(ctx context.Context, command Command, message *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+				calls = append(calls, name)
+				return next(ctx, command, message)
+			}
+		})
+	}
+	rewrite := ExecuteMiddleware(func(next ExecuteHandler) ExecuteHandler {
+		return func(ctx context.Context, command Command, message *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+			message = domain.NewCommandMessage(message.Command(), message.Args(), strings.ToUpper(message.ArgString()), message.Sender(), message.Private(), message.Timestamp())
+			replies, err := next(ctx, command, message)
+			return append(replies, domain.NewClientMessage("done", nil, false)), err
+		}
+	})
+	invoker := NewInvoker(&testExecutor{}, NewCommandList(&testCommand{name: "echo"}), WithMiddlewares(trace("first"), trace("second"), rewrite))
+	message, _ := NewCommandMessage("echo", "hello", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	replies, err := invoker.Execute(context.Background(), message)
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("expected middlewares to be called in order got %v", calls)
+	}
+	if len(replies) != 2 || replies[0].Message() != "echo:HELLO" || replies[1].Message() != "done" {
+		t.Errorf("unexpected replies %v", replies)
+	}
+}
+
+type replyCommand struct {
+	testCommand
+	reply string
+}
+
+func (r *replyCommand) Execute(*domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	return []*domain.ClientMessage{domain.NewClientMessage(r.reply, nil, false)}, nil
+}
+
+func TestInvoker_MiddlewareRedirect(t *testing.T) {
+	replace := ExecuteMiddleware(func(next ExecuteHandler) ExecuteHandler {
+		return func(ctx context.Context, command Command, message *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+			switch message.ArgString() {
+			case "replace":
+				return next(ctx, &replyCommand{testCommand: testCommand{name: "replacement"}, reply: "replaced"}, message)
+			case "rewrite":
+				message = domain.NewCommandMessage("other", message.Args(), message.ArgString(), message.Sender(), message.Private(), message.Timestamp())
+			}
+			return next(ctx, command, message)
+		}
+	})
+	invoker := NewInvoker(&testExecutor{}, NewCommandList(&testCommand{name: "echo"}, &testCommand{name: "other"}), WithMiddlewares(replace))
+	for _, tt := range []struct {
+		argString string
+		want      string
+	}{
+		{argString: "plain", want: "echo:plain"},
+		{argString: "replace", want: "replaced"},
+		{argString: "rewrite", want: "other:rewrite"},
+	} {
+		message, _ := NewCommandMessage("echo", tt.argString, domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+		replies, err := invoker.Execute(context.Background(), message)
+		if err != nil || len(replies) != 1 || replies[0].Message() != tt.want {
+			t.Errorf("expected %v got %v, %v", tt.want, replies, err)
+		}
+	}
+}
+
+func TestInvoker_ChatMiddleware(t *testing.T) {
+	block := ChatMiddleware(func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, command Command, message *domain.ChatMessage) ([]*domain.ClientMessage, error) {
+			if command.Name() == "blocked" {
+				return nil, nil
+			}
+			return next(ctx, command, message)
+		}
+	})
+	list := NewCommandList(&chatCommand{testCommand{name: "blocked"}}, &chatCommand{testCommand{name: "open"}})
+	invoker := NewInvoker(&testExecutor{}, list, WithMiddlewares(block))
+	replies, err := invoker.OnChat(context.Background(), domain.NewChatMessage("hi", domain.NewUser("user", "id", domain.RegularUser), nil, false, false, time.Now(), true))
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if len(replies) != 1 || replies[0].Message() != "open saw hi" {
+		t.Errorf("unexpected replies %v", replies)
+	}
+	replies, _ = invoker.OnChat(context.Background(), domain.NewChatMessage("hi", domain.NewUser("bot", "bot", domain.RegularUser), nil, false, false, time.Now(), false))
+	if len(replies) != 0 {
+		t.Errorf("expected messages from the bot to be ignored got %v", replies)
+	}
+}
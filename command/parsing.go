@@ -1,23 +1,267 @@
 package command
 
 import (
-	"sort"
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func Is(possibleCommand string, cmd Command) bool {
 	if possibleCommand == cmd.Name() {
 		return true
 	}
-	aliases := cmd.Aliases()
-	if len(aliases) == 0 {
-		return false
-	}
-	sort.Strings(aliases)
-	index := sort.SearchStrings(aliases, possibleCommand)
-	if index < len(aliases) && aliases[index] == possibleCommand {
-		return true
+	for _, alias := range cmd.Aliases() {
+		if alias == possibleCommand {
+			return true
+		}
 	}
 	return false
 }
 
 var _ = Is
+
+// ParseError is returned by ParseArguments when a CommandMessage does not match a Schema
+type ParseError struct {
+	Schema *Schema
+	Err    error
+}
+
+func (p *ParseError) Error() string {
+	return p.Err.Error()
+}
+
+func (p *ParseError) Unwrap() error {
+	return p.Err
+}
+
+func parseValue(argumentType ArgumentType, value string) (interface{}, error) {
+	switch argumentType {
+	case IntArgument:
+		return strconv.Atoi(value)
+	case FloatArgument:
+		return strconv.ParseFloat(value, 64)
+	case BoolArgument:
+		return strconv.ParseBool(value)
+	case DurationArgument:
+		return time.ParseDuration(value)
+	default:
+		return value, nil
+	}
+}
+
+// SchemaError is returned by ParseArguments when a Schema itself is invalid.
+// It is a bug in the command rather than a mistake of the user, so it is not a ParseError
+type SchemaError struct {
+	Err error
+}
+
+func (s *SchemaError) Error() string {
+	return "invalid schema: " + s.Err.Error()
+}
+
+func (s *SchemaError) Unwrap() error {
+	return s.Err
+}
+
+// isOfType returns true if value holds the Go type parseValue returns for argumentType
+func isOfType(argumentType ArgumentType, value interface{}) bool {
+	switch value.(type) {
+	case int:
+		return argumentType == IntArgument
+	case float64:
+		return argumentType == FloatArgument
+	case bool:
+		return argumentType == BoolArgument
+	case time.Duration:
+		return argumentType == DurationArgument
+	case string:
+		return argumentType == StringArgument
+	default:
+		return false
+	}
+}
+
+// checkDefault returns an error if value cannot be read back as argumentType. The default of a variadic argument is a []interface{}
+func checkDefault(argumentType ArgumentType, value interface{}, variadic bool) error {
+	if value == nil {
+		return nil
+	}
+	if !variadic {
+		if !isOfType(argumentType, value) {
+			return fmt.Errorf("default %#v is not a %s", value, argumentType)
+		}
+		return nil
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("default %#v is not a []interface{}", value)
+	}
+	for _, v := range values {
+		if !isOfType(argumentType, v) {
+			return fmt.Errorf("default %#v is not a %s", v, argumentType)
+		}
+	}
+	return nil
+}
+
+func validateSchema(schema *Schema) error {
+	schemaError := func(format string, a ...interface{}) error {
+		return &SchemaError{Err: fmt.Errorf(format, a...)}
+	}
+	names := map[string]bool{}
+	optional := false
+	for i, argument := range schema.Arguments {
+		if names[argument.Name] {
+			return schemaError("duplicate argument %s", argument.Name)
+		}
+		names[argument.Name] = true
+		if argument.Variadic && i != len(schema.Arguments)-1 {
+			return schemaError("variadic argument %s must be the last argument", argument.Name)
+		}
+		if argument.Required && optional {
+			return schemaError("required argument %s cannot follow an optional argument", argument.Name)
+		}
+		if err := checkDefault(argument.Type, argument.Default, argument.Variadic); err != nil {
+			return schemaError("argument %s: %v", argument.Name, err)
+		}
+		optional = !argument.Required
+	}
+	for _, flag := range schema.Flags {
+		if names[flag.Name] {
+			return schemaError("duplicate flag --%s", flag.Name)
+		}
+		names[flag.Name] = true
+		if err := checkDefault(flag.Type, flag.Default, false); err != nil {
+			return schemaError("flag --%s: %v", flag.Name, err)
+		}
+	}
+	return nil
+}
+
+// ParseArguments validates the arguments of a CommandMessage against a Schema.
+// It returns a *SchemaError if the Schema is invalid and a *ParseError if the message does not match it
+func ParseArguments(schema *Schema, message *domain.CommandMessage) (*Arguments, error) {
+	if err := validateSchema(schema); err != nil {
+		return nil, err
+	}
+	arguments := newArguments()
+	parseError := func(format string, a ...interface{}) error {
+		return &ParseError{Schema: schema, Err: fmt.Errorf(format, a...)}
+	}
+	var positionals []string
+	args := message.Args()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positionals = append(positionals, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			positionals = append(positionals, arg)
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)
+		name, value, hasValue := parts[0], "", len(parts) == 2
+		if hasValue {
+			value = parts[1]
+		}
+		flag, ok := schema.flag(name)
+		if !ok {
+			return nil, parseError("unknown flag --%s", name)
+		}
+		if !hasValue {
+			if flag.Type == BoolArgument {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return nil, parseError("flag --%s needs a value", name)
+			}
+		}
+		v, err := parseValue(flag.Type, value)
+		if err != nil {
+			return nil, parseError("invalid value %q for flag --%s: expected %s", value, name, flag.Type)
+		}
+		arguments.values[name] = v
+	}
+	for _, flag := range schema.Flags {
+		if arguments.Has(flag.Name) {
+			continue
+		}
+		if flag.Required {
+			return nil, parseError("missing flag --%s", flag.Name)
+		}
+		if flag.Default != nil {
+			arguments.values[flag.Name] = flag.Default
+		}
+	}
+	for i, argument := range schema.Arguments {
+		if i >= len(positionals) {
+			if argument.Required {
+				return nil, parseError("missing argument %s", argument.Name)
+			}
+			if argument.Default != nil {
+				arguments.values[argument.Name] = argument.Default
+			}
+			continue
+		}
+		if argument.Variadic {
+			values := make([]interface{}, 0, len(positionals)-i)
+			for _, positional := range positionals[i:] {
+				v, err := parseValue(argument.Type, positional)
+				if err != nil {
+					return nil, parseError("invalid value %q for argument %s: expected %s", positional, argument.Name, argument.Type)
+				}
+				values = append(values, v)
+			}
+			arguments.values[argument.Name] = values
+			positionals = positionals[:i+1]
+			break
+		}
+		v, err := parseValue(argument.Type, positionals[i])
+		if err != nil {
+			return nil, parseError("invalid value %q for argument %s: expected %s", positionals[i], argument.Name, argument.Type)
+		}
+		arguments.values[argument.Name] = v
+	}
+	if len(positionals) > len(schema.Arguments) {
+		return nil, parseError("too many arguments")
+	}
+	return arguments, nil
+}
+
+// Usage builds a usage string such as `name <required> [optional] [variadic...] --flag=<int>` from a Schema
+func Usage(name string, schema *Schema) string {
+	parts := []string{name}
+	for _, argument := range schema.Arguments {
+		part := argument.Name
+		if argument.Variadic {
+			part += "..."
+		}
+		if argument.Required {
+			part = "<" + part + ">"
+		} else {
+			part = "[" + part + "]"
+		}
+		parts = append(parts, part)
+	}
+	for _, flag := range schema.Flags {
+		part := "--" + flag.Name
+		if flag.Type != BoolArgument {
+			part += "=<" + flag.Type.String() + ">"
+		}
+		if !flag.Required {
+			part = "[" + part + "]"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// UsageReply builds the standard reply sent when a CommandMessage does not match a Schema
+func UsageReply(trigger string, message *domain.CommandMessage, schema *Schema, err error) *domain.ClientMessage {
+	return message.Reply(fmt.Sprintf("%v. Usage: %s%s", err, trigger, Usage(message.Command(), schema)))
+}
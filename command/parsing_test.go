@@ -0,0 +1,126 @@
+package command
+
+import (
+	"errors"
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSchema = &Schema{
+	Arguments: []Argument{
+		{Name: "text", Required: true},
+		{Name: "count", Type: IntArgument, Default: 1},
+		{Name: "rest", Variadic: true},
+	},
+	Flags: []Flag{
+		{Name: "delay", Type: DurationArgument},
+		{Name: "loud", Type: BoolArgument},
+	},
+}
+
+func testCommandMessage(args ...string) *domain.CommandMessage {
+	return domain.NewCommandMessage("test", args, strings.Join(args, " "), domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+}
+
+func TestParseArguments(t *testing.T) {
+	arguments, err := ParseArguments(testSchema, testCommandMessage("hello", "--delay", "5s", "3", "a", "--loud", "b"))
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if arguments.String("text") != "hello" {
+		t.Errorf("expected %v got %v", "hello", arguments.String("text"))
+	}
+	if arguments.Int("count") != 3 {
+		t.Errorf("expected %v got %v", 3, arguments.Int("count"))
+	}
+	if arguments.Duration("delay") != 5*time.Second {
+		t.Errorf("expected %v got %v", 5*time.Second, arguments.Duration("delay"))
+	}
+	if !arguments.Bool("loud") {
+		t.Errorf("expected loud to be set")
+	}
+	if rest := strings.Join(arguments.Strings("rest"), ","); rest != "a,b" {
+		t.Errorf("expected %v got %v", "a,b", rest)
+	}
+}
+
+func TestParseArguments_Defaults(t *testing.T) {
+	arguments, err := ParseArguments(testSchema, testCommandMessage("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if arguments.Int("count") != 1 {
+		t.Errorf("expected %v got %v", 1, arguments.Int("count"))
+	}
+	if arguments.Has("delay") {
+		t.Errorf("expected delay to be absent")
+	}
+}
+
+func TestParseArguments_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "missing argument", args: nil},
+		{name: "invalid int", args: []string{"hello", "three"}},
+		{name: "unknown flag", args: []string{"hello", "--quiet"}},
+		{name: "missing flag value", args: []string{"hello", "--delay"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseArguments(testSchema, testCommandMessage(tt.args...))
+			var parseError *ParseError
+			if !errors.As(err, &parseError) {
+				t.Errorf("expected ParseError got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseArguments_InvalidSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *Schema
+	}{
+		{name: "variadic not last", schema: &Schema{Arguments: []Argument{{Name: "rest", Variadic: true}, {Name: "text"}}}},
+		{name: "required after optional", schema: &Schema{Arguments: []Argument{{Name: "text"}, {Name: "count", Required: true}}}},
+		{name: "duplicate flag", schema: &Schema{Flags: []Flag{{Name: "loud", Type: BoolArgument}, {Name: "loud"}}}},
+		{name: "flag named like an argument", schema: &Schema{Arguments: []Argument{{Name: "text"}}, Flags: []Flag{{Name: "text"}}}},
+		{name: "mismatched default", schema: &Schema{Flags: []Flag{{Name: "ratio", Type: FloatArgument, Default: 1}}}},
+		{name: "mismatched variadic default", schema: &Schema{Arguments: []Argument{{Name: "rest", Type: IntArgument, Variadic: true, Default: []interface{}{"1"}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseArguments(tt.schema, testCommandMessage())
+			var schemaError *SchemaError
+			if !errors.As(err, &schemaError) {
+				t.Errorf("expected SchemaError got %v", err)
+			}
+			var parseError *ParseError
+			if errors.As(err, &parseError) {
+				t.Errorf("expected schema error not to be a ParseError")
+			}
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	want := "test <text> [count] [rest...] [--delay=<duration>] [--loud]"
+	if got := Usage("test", testSchema); got != want {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestIs(t *testing.T) {
+	aliases := []string{"q", "cite"}
+	cmd := &testCommand{name: "quote", aliases: aliases}
+	if !Is("cite", cmd) || !Is("quote", cmd) || Is("quotes", cmd) {
+		t.Errorf("expected quote and its aliases to match")
+	}
+	if aliases[0] != "q" {
+		t.Errorf("expected aliases to be left untouched got %v", aliases)
+	}
+}
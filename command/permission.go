@@ -0,0 +1,37 @@
+package command
+
+import (
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+)
+
+// Restricted should be implemented by commands and subcommands that require a permission to be executed.
+// The Invoker refuses to execute a subcommand if its sender lacks the permission of any command along its path
+type Restricted interface {
+	Permission() domain.Permission
+}
+
+func requiredPermission(cmd Command) domain.Permission {
+	if restricted, ok := cmd.(Restricted); ok {
+		return restricted.Permission()
+	}
+	return domain.IsUnknown
+}
+
+func hasPermission(bot Executor, user *domain.User, chain []Command) bool {
+	for _, cmd := range chain {
+		permission := requiredPermission(cmd)
+		if permission == domain.IsUnknown {
+			continue
+		}
+		if user == nil || !bot.UserHasPermission(user, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// PermissionDeniedReply builds the standard reply sent when the sender of a CommandMessage lacks the required permission
+func PermissionDeniedReply(message *domain.CommandMessage) *domain.ClientMessage {
+	return message.Reply(fmt.Sprintf("You don't have the permission to use %s", message.Command()))
+}
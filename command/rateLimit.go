@@ -0,0 +1,290 @@
+package command
+
+import (
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Limiter decides whether an action identified by a key may happen
+type Limiter interface {
+	// Peek reports whether a slot is available for key without consuming it, and if not how long to wait for the next one
+	Peek(key string, now time.Time) (time.Duration, bool)
+	// Allow consumes a slot for key. If none is available it returns false and how long to wait for the next one
+	Allow(key string, now time.Time) (time.Duration, bool)
+}
+
+var _ Limiter = (*tokenBucket)(nil)
+var _ Limiter = (*slidingWindow)(nil)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type tokenBucket struct {
+	m         *sync.Mutex
+	capacity  float64
+	interval  time.Duration
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func (t *tokenBucket) refill(b *bucket, now time.Time) {
+	b.tokens = math.Min(t.capacity, b.tokens+float64(now.Sub(b.last))/float64(t.interval))
+	b.last = now
+}
+
+func (t *tokenBucket) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < time.Duration(t.capacity)*t.interval {
+		return
+	}
+	t.lastSweep = now
+	for key, b := range t.buckets {
+		t.refill(b, now)
+		if b.tokens >= t.capacity {
+			delete(t.buckets, key)
+		}
+	}
+}
+
+func (t *tokenBucket) take(key string, now time.Time, consume bool) (time.Duration, bool) {
+	t.m.Lock()
+	wait, ok := func() (time.Duration, bool) {
+		t.sweep(now)
+		b, ok := t.buckets[key]
+		if !ok {
+			b = &bucket{tokens: t.capacity, last: now}
+			t.buckets[key] = b
+		}
+		t.refill(b, now)
+		if b.tokens < 1 {
+			return time.Duration((1 - b.tokens) * float64(t.interval)), false
+		}
+		if consume {
+			b.tokens--
+		}
+		return 0, true
+	}()
+	t.m.Unlock()
+	return wait, ok
+}
+
+func (t *tokenBucket) Peek(key string, now time.Time) (time.Duration, bool) {
+	return t.take(key, now, false)
+}
+
+func (t *tokenBucket) Allow(key string, now time.Time) (time.Duration, bool) {
+	return t.take(key, now, true)
+}
+
+// NewTokenBucket creates a Limiter allowing bursts of capacity actions per key, with one action regained every interval
+func NewTokenBucket(capacity int, interval time.Duration) (Limiter, error) {
+	if capacity < 1 || interval <= 0 {
+		return nil, fmt.Errorf("invalid token bucket: capacity %d and interval %s must be positive", capacity, interval)
+	}
+	return &tokenBucket{
+		m:        &sync.Mutex{},
+		capacity: float64(capacity),
+		interval: interval,
+		buckets:  map[string]*bucket{},
+	}, nil
+}
+
+type slidingWindow struct {
+	m         *sync.Mutex
+	limit     int
+	window    time.Duration
+	hits      map[string][]time.Time
+	lastSweep time.Time
+}
+
+func (s *slidingWindow) prune(key string, now time.Time) []time.Time {
+	hits := s.hits[key]
+	i := 0
+	for i < len(hits) && now.Sub(hits[i]) >= s.window {
+		i++
+	}
+	hits = hits[i:]
+	if len(hits) == 0 {
+		delete(s.hits, key)
+		return nil
+	}
+	s.hits[key] = hits
+	return hits
+}
+
+func (s *slidingWindow) take(key string, now time.Time, consume bool) (time.Duration, bool) {
+	s.m.Lock()
+	wait, ok := func() (time.Duration, bool) {
+		if now.Sub(s.lastSweep) >= s.window {
+			s.lastSweep = now
+			for k := range s.hits {
+				s.prune(k, now)
+			}
+		}
+		hits := s.prune(key, now)
+		if len(hits) >= s.limit {
+			return hits[0].Add(s.window).Sub(now), false
+		}
+		if consume {
+			s.hits[key] = append(hits, now)
+		}
+		return 0, true
+	}()
+	s.m.Unlock()
+	return wait, ok
+}
+
+func (s *slidingWindow) Peek(key string, now time.Time) (time.Duration, bool) {
+	return s.take(key, now, false)
+}
+
+func (s *slidingWindow) Allow(key string, now time.Time) (time.Duration, bool) {
+	return s.take(key, now, true)
+}
+
+// NewSlidingWindow creates a Limiter allowing at most limit actions per key during any window
+func NewSlidingWindow(limit int, window time.Duration) (Limiter, error) {
+	if limit < 1 || window <= 0 {
+		return nil, fmt.Errorf("invalid sliding window: limit %d and window %s must be positive", limit, window)
+	}
+	return &slidingWindow{
+		m:      &sync.Mutex{},
+		limit:  limit,
+		window: window,
+		hits:   map[string][]time.Time{},
+	}, nil
+}
+
+// RateLimitScope selects what a RateLimit counts separately.
+// A RateLimit without scope is shared by everyone using the commands it is attached to
+type RateLimitScope int
+
+const (
+	PerUser RateLimitScope = 1 << iota
+	PerCommand
+	PerChannel
+)
+
+type RateLimit struct {
+	Limiter Limiter
+	Scope   RateLimitScope
+	// Users with the Exempt permission are not limited. IsUnknown exempts nobody
+	Exempt domain.Permission
+}
+
+func (r RateLimit) key(path string, message *domain.CommandMessage) string {
+	var parts []string
+	if r.Scope&PerUser != 0 && message.Sender() != nil {
+		parts = append(parts, "user:"+message.Sender().Id())
+	}
+	if r.Scope&PerCommand != 0 {
+		parts = append(parts, "command:"+path)
+	}
+	if r.Scope&PerChannel != 0 {
+		channel := "public"
+		if message.Private() && message.Sender() != nil {
+			channel = "private:" + message.Sender().Id()
+		} else if message.Channel() != nil {
+			channel = message.Channel().Id()
+		}
+		parts = append(parts, "channel:"+channel)
+	}
+	return strings.Join(parts, "|")
+}
+
+// A RateLimiter holds the rate limits attached to commands
+type RateLimiter interface {
+	// Attach adds a RateLimit to a command. Limits attached to a command also apply to its subcommands
+	Attach(command Command, limit RateLimit)
+	// Check consumes a slot of every limit attached to the chain of commands resolved from a CommandMessage.
+	// No slot is consumed if one of the limits rejects the message. A Limiter attached at several levels with the same key is consumed once
+	Check(chain []Command, message *domain.CommandMessage) (time.Duration, bool)
+}
+
+var _ RateLimiter = (*rateLimiter)(nil)
+
+type rateLimiter struct {
+	bot    Executor
+	rwm    *sync.RWMutex
+	limits map[Command][]RateLimit
+	// check serializes Check so that no slot is taken between peeking at the limits and consuming them
+	check *sync.Mutex
+}
+
+func (r *rateLimiter) Attach(command Command, limit RateLimit) {
+	r.rwm.Lock()
+	r.limits[command] = append(r.limits[command], limit)
+	r.rwm.Unlock()
+}
+
+type keyedLimit struct {
+	limiter Limiter
+	key     string
+}
+
+func (r *rateLimiter) Check(chain []Command, message *domain.CommandMessage) (time.Duration, bool) {
+	now := time.Now()
+	var applicable []keyedLimit
+	// seen deduplicates a Limiter attached at several levels of the chain with the same key, so that it is consumed once
+	seen := map[keyedLimit]bool{}
+	var path []string
+	for _, cmd := range chain {
+		path = append(path, cmd.Name())
+		r.rwm.RLock()
+		limits := r.limits[cmd]
+		r.rwm.RUnlock()
+		for _, limit := range limits {
+			if limit.Exempt != domain.IsUnknown && message.Sender() != nil && r.bot.UserHasPermission(message.Sender(), limit.Exempt) {
+				continue
+			}
+			keyed := keyedLimit{limiter: limit.Limiter, key: limit.key(strings.Join(path, " "), message)}
+			if seen[keyed] {
+				continue
+			}
+			seen[keyed] = true
+			applicable = append(applicable, keyed)
+		}
+	}
+	r.check.Lock()
+	wait, allowed := func() (time.Duration, bool) {
+		var wait time.Duration
+		allowed := true
+		for _, limit := range applicable {
+			if w, ok := limit.limiter.Peek(limit.key, now); !ok {
+				allowed = false
+				if w > wait {
+					wait = w
+				}
+			}
+		}
+		if !allowed {
+			return wait, false
+		}
+		for _, limit := range applicable {
+			limit.limiter.Allow(limit.key, now)
+		}
+		return 0, true
+	}()
+	r.check.Unlock()
+	return wait, allowed
+}
+
+func NewRateLimiter(bot Executor) RateLimiter {
+	return &rateLimiter{
+		bot:    bot,
+		rwm:    &sync.RWMutex{},
+		limits: map[Command][]RateLimit{},
+		check:  &sync.Mutex{},
+	}
+}
+
+// CooldownReply builds the standard reply sent when a CommandMessage exceeds a RateLimit
+func CooldownReply(message *domain.CommandMessage, wait time.Duration) *domain.ClientMessage {
+	wait = time.Duration(math.Ceil(wait.Seconds())) * time.Second
+	return message.Reply(fmt.Sprintf("Slow down! You can use %s again in %s", message.Command(), wait))
+}
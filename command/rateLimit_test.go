@@ -0,0 +1,114 @@
+package command
+
+import (
+	"context"
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Allow(t *testing.T) {
+	limiter, _ := NewTokenBucket(2, time.Second)
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, ok := limiter.Allow("key", now); !ok {
+			t.Fatalf("expected burst of %v to be allowed", 2)
+		}
+	}
+	if wait, ok := limiter.Allow("key", now); ok || wait != time.Second {
+		t.Errorf("expected to wait %v got %v", time.Second, wait)
+	}
+	if _, ok := limiter.Allow("other", now); !ok {
+		t.Errorf("expected other key to be allowed")
+	}
+	if _, ok := limiter.Allow("key", now.Add(time.Second)); !ok {
+		t.Errorf("expected token to be refilled")
+	}
+}
+
+func TestSlidingWindow_Allow(t *testing.T) {
+	limiter, _ := NewSlidingWindow(2, time.Minute)
+	now := time.Now()
+	limiter.Allow("key", now)
+	limiter.Allow("key", now.Add(10*time.Second))
+	if wait, ok := limiter.Allow("key", now.Add(20*time.Second)); ok || wait != 40*time.Second {
+		t.Errorf("expected to wait %v got %v", 40*time.Second, wait)
+	}
+	if _, ok := limiter.Allow("key", now.Add(time.Minute)); !ok {
+		t.Errorf("expected oldest hit to leave the window")
+	}
+}
+
+func TestInvoker_RateLimit(t *testing.T) {
+	cmd := &testCommand{name: "weather"}
+	bot := &testExecutor{permissions: map[string]domain.Permission{"admin": domain.IsAdmin}}
+	rateLimiter := NewRateLimiter(bot)
+	limiter, _ := NewSlidingWindow(1, time.Hour)
+	rateLimiter.Attach(cmd, RateLimit{Limiter: limiter, Scope: PerUser | PerCommand, Exempt: domain.NeedAdmin})
+	invoker := NewInvoker(bot, NewCommandList(cmd), WithRateLimiter(rateLimiter))
+	execute := func(userId string) string {
+		message, _ := NewCommandMessage("weather", "paris", domain.NewUser(userId, userId, domain.RegularUser), false, time.Now())
+		replies, _ := invoker.Execute(context.Background(), message)
+		return replies[0].Message()
+	}
+	if reply := execute("user"); reply != "weather:paris" {
+		t.Errorf("expected first call to execute got %v", reply)
+	}
+	if reply := execute("user"); !strings.HasPrefix(reply, "Slow down!") {
+		t.Errorf("expected cooldown reply got %v", reply)
+	}
+	if reply := execute("other"); reply != "weather:paris" {
+		t.Errorf("expected other user to execute got %v", reply)
+	}
+	for i := 0; i < 2; i++ {
+		if reply := execute("admin"); reply != "weather:paris" {
+			t.Errorf("expected admin to be exempt got %v", reply)
+		}
+	}
+}
+
+func TestNewLimiter_Invalid(t *testing.T) {
+	if _, err := NewSlidingWindow(0, time.Minute); err == nil {
+		t.Errorf("expected a zero limit to be rejected")
+	}
+	if _, err := NewTokenBucket(1, 0); err == nil {
+		t.Errorf("expected a zero interval to be rejected")
+	}
+}
+
+func TestRateLimiter_CheckConsumesNothingWhenRejected(t *testing.T) {
+	cmd := &testCommand{name: "weather"}
+	rateLimiter := NewRateLimiter(&testExecutor{})
+	loose, _ := NewSlidingWindow(2, time.Hour)
+	strict, _ := NewSlidingWindow(1, time.Hour)
+	rateLimiter.Attach(cmd, RateLimit{Limiter: loose, Scope: PerUser})
+	rateLimiter.Attach(cmd, RateLimit{Limiter: strict, Scope: PerCommand})
+	user := domain.NewUser("user", "id", domain.RegularUser)
+	message, _ := NewCommandMessage("weather", "", user, false, time.Now())
+	rateLimiter.Check([]Command{cmd}, message)
+	if _, ok := rateLimiter.Check([]Command{cmd}, message); ok {
+		t.Fatalf("expected the strict limit to reject the second call")
+	}
+	if _, ok := loose.Peek("user:id", time.Now()); !ok {
+		t.Errorf("expected the rejected call not to consume a slot of the loose limit")
+	}
+}
+
+func TestRateLimiter_CheckConsumesSharedLimitOnce(t *testing.T) {
+	child := &testCommand{name: "today"}
+	parent := &testCommand{name: "weather", subcommands: []Command{child}}
+	rateLimiter := NewRateLimiter(&testExecutor{})
+	shared, _ := NewSlidingWindow(2, time.Hour)
+	rateLimiter.Attach(parent, RateLimit{Limiter: shared, Scope: PerUser})
+	rateLimiter.Attach(child, RateLimit{Limiter: shared, Scope: PerUser})
+	message, _ := NewCommandMessage("weather", "today", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	for i := 0; i < 2; i++ {
+		if _, ok := rateLimiter.Check([]Command{parent, child}, message); !ok {
+			t.Fatalf("expected call %d to be allowed", i+1)
+		}
+	}
+	if _, ok := rateLimiter.Check([]Command{parent, child}, message); ok {
+		t.Errorf("expected the third call to be rejected")
+	}
+}
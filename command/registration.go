@@ -0,0 +1,48 @@
+package command
+
+import "github.com/raf924/connector-sdk/domain"
+
+// Documented can be implemented by a Command to describe how it is used.
+// Commands implementing ArgumentsExecutable otherwise get a usage built from their Schema
+type Documented interface {
+	Usage() string
+}
+
+func describe(path string, cmd Command) *domain.Command {
+	var usage string
+	if documented, ok := cmd.(Documented); ok {
+		usage = documented.Usage()
+	} else if executable, ok := cmd.(ArgumentsExecutable); ok {
+		usage = Usage(path, executable.Schema())
+	}
+	var subcommands []*domain.Command
+	if parent, ok := cmd.(Parent); ok {
+		for _, sub := range parent.Subcommands() {
+			subcommands = append(subcommands, describe(path+" "+sub.Name(), sub))
+		}
+	}
+	return domain.NewCommand(cmd.Name(), cmd.Aliases(), usage, domain.WithSubcommands(subcommands...), domain.WithPermission(requiredPermission(cmd)))
+}
+
+// Describe builds the registration metadata of a Command, including its subcommand tree
+func Describe(cmd Command) *domain.Command {
+	return describe(cmd.Name(), cmd)
+}
+
+// describeSafely is Describe turning a panic of the command, such as the one of NoOpCommand.Name, into an error
+func describeSafely(cmd Command) (description *domain.Command, err error) {
+	defer recoverError(&err)
+	return Describe(cmd), nil
+}
+
+// NewRegistrationMessage describes every command of the list to be sent to the connector
+func NewRegistrationMessage(list List) *domain.RegistrationMessage {
+	var commands []*domain.Command
+	list.Range(func(cmd Command) bool {
+		commands = append(commands, Describe(cmd))
+		return true
+	})
+	return domain.NewRegistrationMessage(commands)
+}
+
+var _ = NewRegistrationMessage
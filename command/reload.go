@@ -0,0 +1,78 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/raf924/connector-sdk/rpc"
+)
+
+func registrationUpdater(relay rpc.DispatcherRelay) (rpc.RegistrationUpdater, error) {
+	updater, ok := relay.(rpc.RegistrationUpdater)
+	if !ok {
+		return nil, fmt.Errorf("dispatcher relay cannot update its registration")
+	}
+	return updater, nil
+}
+
+// waitIdle waits for the running executions of a command removed from list, if the list tracks them
+func waitIdle(list List, command Command) {
+	if tracker, ok := list.(executionTracker); ok {
+		<-tracker.idle(command)
+	}
+}
+
+// SendRegistration sends the commands of the list to the connector through a relay implementing rpc.RegistrationUpdater
+func SendRegistration(list List, relay rpc.DispatcherRelay) error {
+	updater, err := registrationUpdater(relay)
+	if err != nil {
+		return err
+	}
+	return updater.UpdateRegistration(NewRegistrationMessage(list))
+}
+
+// Reload initializes replacement, swaps it with the command of the list named like it and sends the updated registration.
+// The previous command is kept if the replacement cannot take its place, otherwise it is shut down
+// once the executions an Invoker started with it have returned.
+// Nothing changes if the relay does not implement rpc.RegistrationUpdater
+func Reload(bot Executor, list List, relay rpc.DispatcherRelay, replacement Command) error {
+	updater, err := registrationUpdater(relay)
+	if err != nil {
+		return err
+	}
+	name := nameOf(replacement)
+	if err := InitCommand(bot, replacement); err != nil {
+		return fmt.Errorf("cannot initialize %s: %w", name, err)
+	}
+	previous := list.Find(name)
+	if err := list.Replace(replacement); err != nil {
+		_ = ShutdownCommand(context.Background(), replacement)
+		return err
+	}
+	if previous != nil {
+		waitIdle(list, previous)
+		_ = ShutdownCommand(context.Background(), previous)
+	}
+	return updater.UpdateRegistration(NewRegistrationMessage(list))
+}
+
+var _ = Reload
+
+// Unload removes a command from the list, shuts it down once its running executions have returned and sends the updated registration.
+// Nothing changes if the relay does not implement rpc.RegistrationUpdater
+func Unload(list List, relay rpc.DispatcherRelay, command string) error {
+	updater, err := registrationUpdater(relay)
+	if err != nil {
+		return err
+	}
+	previous := list.Find(command)
+	if err := list.Remove(command); err != nil {
+		return err
+	}
+	if previous != nil {
+		waitIdle(list, previous)
+		_ = ShutdownCommand(context.Background(), previous)
+	}
+	return updater.UpdateRegistration(NewRegistrationMessage(list))
+}
+
+var _ = Unload
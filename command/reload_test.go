@@ -0,0 +1,171 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/raf924/connector-sdk/domain"
+	"github.com/raf924/connector-sdk/rpc"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testDispatcherRelay struct {
+	registration *domain.RegistrationMessage
+	m            sync.Mutex
+	sent         []*domain.ClientMessage
+	done         chan struct{}
+}
+
+func (t *testDispatcherRelay) Connect(registration *domain.RegistrationMessage) (*domain.ConfirmationMessage, error) {
+	t.registration = registration
+	return domain.NewConfirmationMessage(domain.NewUser("bot", "bot", domain.RegularUser), "!", nil), nil
+}
+
+func (t *testDispatcherRelay) Send(packet *domain.ClientMessage) error {
+	t.m.Lock()
+	t.sent = append(t.sent, packet)
+	t.m.Unlock()
+	return nil
+}
+
+func (t *testDispatcherRelay) Sent() []string {
+	t.m.Lock()
+	sent := make([]string, len(t.sent))
+	for i, message := range t.sent {
+		sent[i] = message.Message()
+	}
+	t.m.Unlock()
+	return sent
+}
+
+func (t *testDispatcherRelay) Recv() (domain.ServerMessage, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (t *testDispatcherRelay) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *testDispatcherRelay) Err() error {
+	return nil
+}
+
+func (t *testDispatcherRelay) UpdateRegistration(registration *domain.RegistrationMessage) error {
+	t.registration = registration
+	return nil
+}
+
+type initCommand struct {
+	testCommand
+	initErr     error
+	initialized bool
+}
+
+func (i *initCommand) Init(Executor) error {
+	i.initialized = i.initErr == nil
+	return i.initErr
+}
+
+func TestReload(t *testing.T) {
+	old := &testCommand{name: "quote", aliases: []string{"q"}}
+	list := NewCommandList(&testCommand{name: "weather"}, old)
+	relay := &testDispatcherRelay{}
+
+	failing := &initCommand{testCommand: testCommand{name: "quote"}, initErr: errors.New("no database")}
+	if err := Reload(&testExecutor{}, list, relay, failing); err == nil {
+		t.Errorf("expected initialization error")
+	}
+	if list.Find("quote") != old {
+		t.Errorf("expected failed reload to keep the previous command")
+	}
+
+	replacement := &initCommand{testCommand: testCommand{name: "quote", aliases: []string{"quotes"}}}
+	if err := Reload(&testExecutor{}, list, relay, replacement); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if !replacement.initialized {
+		t.Errorf("expected replacement to be initialized")
+	}
+	if list.Find("quotes") != replacement || list.Find("q") != nil || list.Get(1) != replacement {
+		t.Errorf("expected replacement to take the place of the previous command")
+	}
+	if relay.registration == nil || len(relay.registration.Commands()) != 2 || relay.registration.Commands()[1].Aliases()[0] != "quotes" {
+		t.Errorf("expected updated registration to be sent")
+	}
+
+	if err := Unload(list, relay, "weather"); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if list.Find("weather") != nil || list.Find("quote") != replacement {
+		t.Errorf("expected weather to be removed and indexes to be updated")
+	}
+	if len(relay.registration.Commands()) != 1 {
+		t.Errorf("expected %v registered commands got %v", 1, len(relay.registration.Commands()))
+	}
+}
+
+func TestReload_Rejected(t *testing.T) {
+	var stopped []string
+	quote := &stoppableCommand{testCommand: testCommand{name: "quote", aliases: []string{"q"}}, stopped: &stopped}
+	list := NewCommandList(quote)
+	relay := &testDispatcherRelay{}
+
+	byAlias := &stoppableCommand{testCommand: testCommand{name: "q"}, stopped: &stopped}
+	if err := Reload(&testExecutor{}, list, relay, byAlias); err == nil {
+		t.Errorf("expected a replacement named like an alias to be rejected")
+	}
+	if list.Find("quote") != quote || !reflect.DeepEqual(stopped, []string{"q"}) {
+		t.Errorf("expected quote to be kept running and the rejected replacement to be shut down got %v", stopped)
+	}
+
+	var noUpdater rpc.DispatcherRelay = struct{ rpc.DispatcherRelay }{relay}
+	replacement := &initCommand{testCommand: testCommand{name: "quote"}}
+	if err := Reload(&testExecutor{}, list, noUpdater, replacement); err == nil {
+		t.Errorf("expected a relay unable to update its registration to be rejected")
+	}
+	if replacement.initialized || list.Find("quote") != quote {
+		t.Errorf("expected the list to be left untouched")
+	}
+}
+
+func TestReload_WaitsForExecutions(t *testing.T) {
+	var stopped []string
+	quote := &stoppableCommand{testCommand: testCommand{name: "quote"}, started: make(chan struct{}), release: make(chan struct{}), stopped: &stopped}
+	list := NewCommandList(quote)
+	invoker := NewInvoker(&testExecutor{}, list)
+	message, _ := NewCommandMessage("quote", "", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	executed := make(chan struct{})
+	go func() {
+		_, _ = invoker.Execute(context.Background(), message)
+		close(executed)
+	}()
+	<-quote.started
+
+	replacement := &stoppableCommand{testCommand: testCommand{name: "quote"}, stopped: &stopped}
+	reloaded := make(chan error)
+	go func() {
+		reloaded <- Reload(&testExecutor{}, list, &testDispatcherRelay{}, replacement)
+	}()
+	for list.Find("quote") != replacement {
+		runtime.Gosched()
+	}
+	select {
+	case <-reloaded:
+		t.Fatalf("expected reload to wait for the running execution")
+	default:
+	}
+	if len(stopped) != 0 {
+		t.Errorf("expected previous command to keep running got %v", stopped)
+	}
+	close(quote.release)
+	if err := <-reloaded; err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	<-executed
+	if !quote.finished || !reflect.DeepEqual(stopped, []string{"quote"}) {
+		t.Errorf("expected previous command to be shut down after its execution got %v", stopped)
+	}
+}
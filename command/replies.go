@@ -0,0 +1,156 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/raf924/connector-sdk/domain"
+	"github.com/raf924/connector-sdk/rpc"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrSinkClosed is returned when sending through a closed ReplySink
+var ErrSinkClosed = errors.New("reply sink is closed")
+
+// A ReplySink lets commands send messages at any time, including after they returned
+type ReplySink interface {
+	// Send sends message right away. It returns ErrSinkClosed once the sink is closed
+	Send(message *domain.ClientMessage) error
+	// SendAfter sends message once delay has elapsed, unless cancel is called before
+	SendAfter(delay time.Duration, message *domain.ClientMessage) (cancel func())
+	// SendEvery sends the message returned by produce every interval until produce returns nil or cancel is called
+	SendEvery(interval time.Duration, produce func() *domain.ClientMessage) (cancel func())
+	// Close cancels every pending delayed and periodic send. Nothing is sent once it returns
+	Close()
+}
+
+var _ ReplySink = (*replySink)(nil)
+
+type replySink struct {
+	relay rpc.DispatcherRelay
+	// m is held for reading while sending, so that Close waits for the sends in progress
+	m       *sync.RWMutex
+	nextId  int
+	pending map[int]func()
+	closed  bool
+}
+
+func (r *replySink) Send(message *domain.ClientMessage) error {
+	r.m.RLock()
+	err := func() error {
+		if r.closed {
+			return ErrSinkClosed
+		}
+		return r.relay.Send(message)
+	}()
+	r.m.RUnlock()
+	return err
+}
+
+// send sends a delayed or periodic message, dropping it if the sink was closed meanwhile
+func (r *replySink) send(message *domain.ClientMessage) {
+	if err := r.Send(message); err != nil && err != ErrSinkClosed {
+		log.Println("cannot send reply:", err)
+	}
+}
+
+// track registers stop to be called on Close and returns a cancel func removing it
+func (r *replySink) track(stop func()) func() {
+	r.m.Lock()
+	if r.closed {
+		r.m.Unlock()
+		stop()
+		return func() {}
+	}
+	id := r.nextId
+	r.nextId++
+	r.pending[id] = stop
+	r.m.Unlock()
+	return func() {
+		r.m.Lock()
+		_, ok := r.pending[id]
+		delete(r.pending, id)
+		r.m.Unlock()
+		if ok {
+			stop()
+		}
+	}
+}
+
+func (r *replySink) SendAfter(delay time.Duration, message *domain.ClientMessage) func() {
+	timer := time.NewTimer(delay)
+	done := make(chan struct{})
+	cancel := r.track(func() {
+		timer.Stop()
+		close(done)
+	})
+	go func() {
+		select {
+		case <-done:
+		case <-timer.C:
+			r.send(message)
+			cancel()
+		}
+	}()
+	return cancel
+}
+
+func (r *replySink) SendEvery(interval time.Duration, produce func() *domain.ClientMessage) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	cancel := r.track(func() {
+		ticker.Stop()
+		close(done)
+	})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				message := produce()
+				if message == nil {
+					cancel()
+					return
+				}
+				r.send(message)
+			}
+		}
+	}()
+	return cancel
+}
+
+func (r *replySink) Close() {
+	r.m.Lock()
+	r.closed = true
+	pending := r.pending
+	r.pending = map[int]func(){}
+	r.m.Unlock()
+	for _, stop := range pending {
+		stop()
+	}
+}
+
+// NewReplySink creates a ReplySink sending messages through the relay
+func NewReplySink(relay rpc.DispatcherRelay) ReplySink {
+	return &replySink{
+		relay:   relay,
+		m:       &sync.RWMutex{},
+		pending: map[int]func(){},
+	}
+}
+
+type replySinkKey struct{}
+
+func withReplySink(ctx context.Context, sink ReplySink) context.Context {
+	return context.WithValue(ctx, replySinkKey{}, sink)
+}
+
+// Replies returns the ReplySink of the Invoker executing a command, nil if it has none
+func Replies(ctx context.Context) ReplySink {
+	sink, _ := ctx.Value(replySinkKey{}).(ReplySink)
+	return sink
+}
+
+var _ = Replies
@@ -0,0 +1,94 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type countdownCommand struct {
+	testCommand
+}
+
+func (c *countdownCommand) ExecuteContext(ctx context.Context, command *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	count := 3
+	Replies(ctx).SendEvery(time.Millisecond, func() *domain.ClientMessage {
+		if count == 0 {
+			return nil
+		}
+		count--
+		return domain.NewClientMessage(fmt.Sprint(count), command.Sender(), command.Private())
+	})
+	Replies(ctx).SendAfter(time.Hour, domain.NewClientMessage("too late", command.Sender(), command.Private()))
+	return []*domain.ClientMessage{domain.NewClientMessage("starting", command.Sender(), command.Private())}, nil
+}
+
+// blockingRelay signals every send on entered and waits for release before recording it
+type blockingRelay struct {
+	testDispatcherRelay
+	entered chan string
+	release chan struct{}
+}
+
+func (b *blockingRelay) Send(packet *domain.ClientMessage) error {
+	b.entered <- packet.Message()
+	<-b.release
+	return b.testDispatcherRelay.Send(packet)
+}
+
+func TestReplySink(t *testing.T) {
+	relay := &blockingRelay{entered: make(chan string), release: make(chan struct{})}
+	close(relay.release)
+	sink := NewReplySink(relay)
+	invoker := NewInvoker(&testExecutor{}, NewCommandList(&countdownCommand{testCommand{name: "countdown"}}), WithReplySink(sink))
+	message, _ := NewCommandMessage("countdown", "", domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	replies, err := invoker.Execute(context.Background(), message)
+	if err != nil || len(replies) != 1 {
+		t.Fatalf("unexpected result %v, %v", replies, err)
+	}
+	var sent []string
+	for len(sent) < 3 {
+		sent = append(sent, <-relay.entered)
+	}
+	if !reflect.DeepEqual(sent, []string{"2", "1", "0"}) {
+		t.Errorf("expected periodic replies got %v", sent)
+	}
+	_ = invoker.Shutdown(context.Background())
+	if len(sink.(*replySink).pending) != 0 {
+		t.Errorf("expected pending sends to be cancelled on shutdown")
+	}
+	if err := sink.Send(domain.NewClientMessage("closed", nil, false)); !errors.Is(err, ErrSinkClosed) {
+		t.Errorf("expected %v got %v", ErrSinkClosed, err)
+	}
+}
+
+func TestReplySink_Close(t *testing.T) {
+	relay := &blockingRelay{entered: make(chan string), release: make(chan struct{})}
+	sink := NewReplySink(relay)
+	sink.SendAfter(0, domain.NewClientMessage("in flight", nil, false))
+	<-relay.entered
+	closed := make(chan struct{})
+	go func() {
+		sink.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Errorf("expected Close to wait for the send in progress")
+	default:
+	}
+	close(relay.release)
+	<-closed
+	if sent := relay.Sent(); !reflect.DeepEqual(sent, []string{"in flight"}) {
+		t.Errorf("expected the send in progress to complete got %v", sent)
+	}
+	// a timer firing at the same time as Close reaches send once the sink is closed
+	sink.(*replySink).send(domain.NewClientMessage("late", nil, false))
+	if sent := relay.Sent(); len(sent) != 1 {
+		t.Errorf("expected closed sink not to send got %v", sent)
+	}
+}
@@ -0,0 +1,78 @@
+package command
+
+import (
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+	"unicode"
+)
+
+// Parent should be implemented by a Command that groups subcommands.
+// The first argument of a CommandMessage targeting a Parent is matched against the Name and Aliases of its subcommands,
+// recursively, so that the deepest matching subcommand gets executed
+type Parent interface {
+	Subcommands() []Command
+}
+
+func subcommand(cmd Command, name string) Command {
+	parent, ok := cmd.(Parent)
+	if !ok {
+		return nil
+	}
+	for _, sub := range parent.Subcommands() {
+		if Is(name, sub) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// resolve returns the chain of commands, starting with cmd, whose names match path
+func resolve(cmd Command, path []string) []Command {
+	chain := []Command{cmd}
+	for len(chain)-1 < len(path) {
+		sub := subcommand(chain[len(chain)-1], path[len(chain)-1])
+		if sub == nil {
+			break
+		}
+		chain = append(chain, sub)
+	}
+	return chain
+}
+
+func resolveMessage(list List, message *domain.CommandMessage) ([]Command, *domain.CommandMessage) {
+	cmd := list.Find(message.Command())
+	if cmd == nil {
+		return nil, message
+	}
+	// the arguments are taken from the tokenized ArgString so that they match the ArgString given to the subcommand.
+	// Args is only used when ArgString cannot be tokenized
+	tokens, err := tokenize(message.ArgString())
+	args := message.Args()
+	if err == nil {
+		args = make([]string, len(tokens))
+		for i, token := range tokens {
+			args[i] = token.value
+		}
+	}
+	chain := resolve(cmd, args)
+	depth := len(chain) - 1
+	if depth == 0 {
+		return chain, message
+	}
+	path := append([]string{message.Command()}, args[:depth]...)
+	argString := strings.Join(args[depth:], " ")
+	if err == nil {
+		argString = strings.TrimLeftFunc(message.ArgString()[tokens[depth-1].end:], unicode.IsSpace)
+	}
+	return chain, domain.NewCommandMessage(strings.Join(path, " "), args[depth:], argString, message.Sender(), message.Private(), message.Timestamp(), message.MessageOptions()...)
+}
+
+// Resolve finds the deepest command of the list matching a CommandMessage.
+// The returned CommandMessage is stripped of the subcommand names and its Command is the path of the resolved command (e.g. "quote add")
+func Resolve(list List, message *domain.CommandMessage) (Command, *domain.CommandMessage) {
+	chain, message := resolveMessage(list, message)
+	if chain == nil {
+		return nil, message
+	}
+	return chain[len(chain)-1], message
+}
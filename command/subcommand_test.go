@@ -0,0 +1,85 @@
+package command
+
+import (
+	"github.com/raf924/connector-sdk/domain"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCommand struct {
+	NoOpCommand
+	name        string
+	aliases     []string
+	subcommands []Command
+}
+
+func (t *testCommand) Name() string {
+	return t.name
+}
+
+func (t *testCommand) Aliases() []string {
+	return t.aliases
+}
+
+func (t *testCommand) Subcommands() []Command {
+	return t.subcommands
+}
+
+func (t *testCommand) Execute(command *domain.CommandMessage) ([]*domain.ClientMessage, error) {
+	return []*domain.ClientMessage{domain.NewClientMessage(command.Command()+":"+command.ArgString(), command.Sender(), command.Private())}, nil
+}
+
+var quoteCommand = &testCommand{
+	name: "quote",
+	subcommands: []Command{
+		&testCommand{name: "add", aliases: []string{"new"}},
+		&testCommand{name: "del", subcommands: []Command{&testCommand{name: "all"}}},
+	},
+}
+
+func TestResolve(t *testing.T) {
+	list := NewCommandList(quoteCommand)
+	message, _ := NewCommandMessage("quote", `new  "to be" or not`, domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	cmd, resolved := Resolve(list, message)
+	if cmd != quoteCommand.subcommands[0] {
+		t.Fatalf("expected add subcommand got %v", cmd)
+	}
+	if resolved.Command() != "quote new" {
+		t.Errorf("expected %v got %v", "quote new", resolved.Command())
+	}
+	if resolved.ArgString() != `"to be" or not` {
+		t.Errorf("expected %v got %v", `"to be" or not`, resolved.ArgString())
+	}
+	if !reflect.DeepEqual(resolved.Args(), []string{"to be", "or", "not"}) {
+		t.Errorf("unexpected args %q", resolved.Args())
+	}
+	// a relay splitting Args on whitespace disagrees with the quotes of ArgString
+	argString := `new "to be" or`
+	split := domain.NewCommandMessage("quote", strings.Fields(argString), argString, domain.NewUser("user", "id", domain.RegularUser), false, time.Now())
+	if _, resolved := Resolve(list, split); resolved.ArgString() != `"to be" or` || !reflect.DeepEqual(resolved.Args(), []string{"to be", "or"}) {
+		t.Errorf("expected args matching %q got %q", resolved.ArgString(), resolved.Args())
+	}
+}
+
+func TestCommandList_Find(t *testing.T) {
+	list := NewCommandList(quoteCommand)
+	deepest := quoteCommand.subcommands[1].(*testCommand).subcommands[0]
+	if cmd := list.Find("quote del all"); cmd != deepest {
+		t.Errorf("expected %v got %v", deepest, cmd)
+	}
+	if cmd := list.Find("quote random"); cmd != quoteCommand {
+		t.Errorf("expected %v got %v", quoteCommand, cmd)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	command := Describe(quoteCommand)
+	if len(command.Subcommands()) != 2 {
+		t.Fatalf("expected %v subcommands got %v", 2, len(command.Subcommands()))
+	}
+	if command.Subcommand("new") == nil || command.Subcommand("del").Subcommand("all") == nil {
+		t.Errorf("expected subcommand tree to be described")
+	}
+}
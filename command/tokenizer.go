@@ -0,0 +1,115 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ErrUnterminatedQuote = errors.New("unterminated quote")
+var ErrTrailingEscape = errors.New("trailing escape character")
+
+type token struct {
+	value string
+	// start and end are the byte offsets of the raw token in the tokenized string
+	start int
+	end   int
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	var current strings.Builder
+	var quote rune
+	inToken := false
+	escaped := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+			current.WriteRune(r)
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, token{value: current.String(), start: start, end: i})
+				current.Reset()
+				inToken = false
+			}
+			continue
+		default:
+			current.WriteRune(r)
+		}
+		if !inToken {
+			inToken = true
+			start = i
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("%w at position %d", ErrTrailingEscape, len(s)-1)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("%w (%c) in token starting at position %d", ErrUnterminatedQuote, quote, start)
+	}
+	if inToken {
+		tokens = append(tokens, token{value: current.String(), start: start, end: len(s)})
+	}
+	return tokens, nil
+}
+
+// Tokenize splits an argument string like a shell would.
+// Tokens are separated by unicode whitespace, single quotes preserve their content literally,
+// double quotes allow backslash escapes, and a backslash outside of quotes escapes the next character
+func Tokenize(argString string) ([]string, error) {
+	tokens, err := tokenize(argString)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(tokens))
+	for i, t := range tokens {
+		args[i] = t.value
+	}
+	return args, nil
+}
+
+// NewCommandMessage builds a domain.CommandMessage whose Args are the tokens of argString
+func NewCommandMessage(command string, argString string, sender *domain.User, private bool, timestamp time.Time, options ...domain.MessageOption) (*domain.CommandMessage, error) {
+	argString = strings.TrimSpace(argString)
+	args, err := Tokenize(argString)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewCommandMessage(command, args, argString, sender, private, timestamp, options...), nil
+}
+
+// ParseCommandMessage turns a ChatMessage starting with trigger into a CommandMessage sharing its id and channel.
+// It returns nil if the message is not a command
+func ParseCommandMessage(trigger string, message *domain.ChatMessage) (*domain.CommandMessage, error) {
+	text := strings.TrimLeftFunc(message.Message(), unicode.IsSpace)
+	if len(trigger) == 0 || !strings.HasPrefix(text, trigger) {
+		return nil, nil
+	}
+	text = strings.TrimPrefix(text, trigger)
+	end := strings.IndexFunc(text, unicode.IsSpace)
+	if end < 0 {
+		end = len(text)
+	}
+	command := text[:end]
+	if len(command) == 0 {
+		return nil, nil
+	}
+	return NewCommandMessage(command, text[end:], message.Sender(), message.Private(), message.Timestamp(), message.MessageOptions()...)
+}
+
+var _ = ParseCommandMessage
@@ -0,0 +1,66 @@
+package command
+
+import (
+	"errors"
+	"github.com/raf924/connector-sdk/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{name: "empty", s: "  ", want: []string{}},
+		{name: "whitespace", s: " a\tb c　d ", want: []string{"a", "b", "c", "d"}},
+		{name: "double quotes", s: `say "hello world" now`, want: []string{"say", "hello world", "now"}},
+		{name: "single quotes", s: `'a \"b' c`, want: []string{`a \"b`, "c"}},
+		{name: "escapes", s: `a\ b "c\"d" \'e`, want: []string{"a b", `c"d`, "'e"}},
+		{name: "adjacent quotes", s: `--name="foo bar"baz`, want: []string{"--name=foo barbaz"}},
+		{name: "empty quotes", s: `a "" b`, want: []string{"a", "", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Tokenize(tt.s)
+			if err != nil {
+				t.Fatalf("unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTokenize_Errors(t *testing.T) {
+	if _, err := Tokenize(`a "b c`); !errors.Is(err, ErrUnterminatedQuote) {
+		t.Errorf("expected %v got %v", ErrUnterminatedQuote, err)
+	}
+	if _, err := Tokenize(`a b\`); !errors.Is(err, ErrTrailingEscape) {
+		t.Errorf("expected %v got %v", ErrTrailingEscape, err)
+	}
+}
+
+func TestParseCommandMessage(t *testing.T) {
+	chatMessage := domain.NewChatMessage(`!quote add "to be or not"  `, domain.NewUser("user", "id", domain.RegularUser), nil, false, false, time.Now(), true)
+	message, err := ParseCommandMessage("!", chatMessage)
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if message.Command() != "quote" {
+		t.Errorf("expected %v got %v", "quote", message.Command())
+	}
+	if message.ArgString() != `add "to be or not"` {
+		t.Errorf("expected %v got %v", `add "to be or not"`, message.ArgString())
+	}
+	if !reflect.DeepEqual(message.Args(), []string{"add", "to be or not"}) {
+		t.Errorf("unexpected args %q", message.Args())
+	}
+	message, _ = ParseCommandMessage("!", domain.NewChatMessage("hello", nil, nil, false, false, time.Now(), true))
+	if message != nil {
+		t.Errorf("expected nil got %v", message)
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+// A Channel is a room of a chat platform. A connector bridging several rooms sets the Channel of the messages and events
+type Channel struct {
+	id    string
+	name  string
+	users UserList
+}
+
+func (c *Channel) Id() string {
+	return c.id
+}
+
+func (c *Channel) Name() string {
+	return c.name
+}
+
+// Users returns the members of the channel. Connectors keep it up to date as users join and leave
+func (c *Channel) Users() UserList {
+	return c.users
+}
+
+// Is compares channels by id
+func (c *Channel) Is(channel *Channel) bool {
+	return channel != nil && c.id == channel.id
+}
+
+func NewChannel(id string, name string, users ...*User) *Channel {
+	return &Channel{id: id, name: name, users: NewUserList(users...)}
+}
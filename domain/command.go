@@ -1,9 +1,27 @@
 package domain
 
 type Command struct {
-	name    string
-	aliases []string
-	usage   string
+	name        string
+	aliases     []string
+	usage       string
+	subcommands []*Command
+	permission  Permission
+}
+
+type CommandOption func(command *Command)
+
+// WithSubcommands attaches subcommands to a Command so that connectors can render help for them
+func WithSubcommands(subcommands ...*Command) CommandOption {
+	return func(command *Command) {
+		command.subcommands = subcommands
+	}
+}
+
+// WithPermission declares the Permission a user needs to execute a Command
+func WithPermission(permission Permission) CommandOption {
+	return func(command *Command) {
+		command.permission = permission
+	}
 }
 
 func (c *Command) Name() string {
@@ -18,6 +36,33 @@ func (c *Command) Usage() string {
 	return c.usage
 }
 
-func NewCommand(name string, aliases []string, usage string) *Command {
-	return &Command{name: name, aliases: aliases, usage: usage}
+func (c *Command) Subcommands() []*Command {
+	return c.subcommands
+}
+
+func (c *Command) Permission() Permission {
+	return c.permission
+}
+
+// Subcommand returns the direct subcommand matching name or one of its aliases
+func (c *Command) Subcommand(name string) *Command {
+	for _, subcommand := range c.subcommands {
+		if subcommand.name == name {
+			return subcommand
+		}
+		for _, alias := range subcommand.aliases {
+			if alias == name {
+				return subcommand
+			}
+		}
+	}
+	return nil
+}
+
+func NewCommand(name string, aliases []string, usage string, options ...CommandOption) *Command {
+	command := &Command{name: name, aliases: aliases, usage: usage}
+	for _, option := range options {
+		option(command)
+	}
+	return command
 }
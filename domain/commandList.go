@@ -1,17 +1,41 @@
 package domain
 
 import (
-	"strings"
+	"fmt"
+	"github.com/raf924/connector-sdk/internal/commandindex"
+	"log"
+	"regexp"
 	"sync"
 )
 
+var commandNameRegexp = regexp.MustCompile(`^[a-z]([0-9]|[a-z])*$`)
+
+// ValidateCommandName checks that a command name or alias is a lowercase alphanumerical string starting with a letter
+func ValidateCommandName(name string) error {
+	if !commandNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid command name %q: must match /%s/", name, commandNameRegexp)
+	}
+	return nil
+}
+
+// ConflictPolicy decides what happens when a command is added with a name or alias already in use
+type ConflictPolicy int
+
+const (
+	// RejectConflicts refuses to add a command conflicting with an existing one
+	RejectConflicts ConflictPolicy = iota
+	// LastWins gives the conflicting names to the added command.
+	// A command losing its name is removed, a command losing an alias keeps its other names
+	LastWins
+)
+
 type CommandList interface {
 	Copy() CommandList
 	All() []*Command
 	Get(i int) *Command
 	Find(command string) *Command
-	Add(command *Command)
-	Append(list CommandList)
+	Add(command *Command) error
+	Append(list CommandList) error
 }
 
 var _ CommandList = (*immutableCommandList)(nil)
@@ -22,11 +46,11 @@ type immutableCommandList struct {
 	CommandList
 }
 
-func (i *immutableCommandList) Add(*Command) {
+func (i *immutableCommandList) Add(*Command) error {
 	panic("cannot modify list")
 }
 
-func (i *immutableCommandList) Append(CommandList) {
+func (i *immutableCommandList) Append(CommandList) error {
 	panic("cannot modify list")
 }
 
@@ -37,77 +61,137 @@ func ImmutableCommandList(cl CommandList) CommandList {
 var _ = ImmutableCommandList
 
 type commandList struct {
-	rwm            *sync.RWMutex
-	commands       []*Command
-	commandIndexes map[string]int
+	rwm    *sync.RWMutex
+	policy ConflictPolicy
+	index  *commandindex.Index[*Command]
 }
 
-func (l *commandList) Append(list CommandList) {
-	for _, command := range list.All() {
-		l.Add(command)
+// Append adds every command of list, or none of them if one is invalid or cannot be added
+func (l *commandList) Append(list CommandList) error {
+	commands := list.All()
+	for _, command := range commands {
+		if err := ValidateCommand(command); err != nil {
+			return err
+		}
 	}
+	l.rwm.Lock()
+	err := func() error {
+		index := newIndex(l.policy)
+		for _, command := range append(l.index.Commands(), commands...) {
+			if err := index.Add(command, namesOf(command)); err != nil {
+				return err
+			}
+		}
+		l.index = index
+		return nil
+	}()
+	l.rwm.Unlock()
+	return err
 }
 
 func (l *commandList) Copy() CommandList {
-	return NewCommandList(l.All()...)
+	list, _ := NewCommandListWithPolicy(l.policy, l.All()...)
+	return list
 }
 
 func (l *commandList) All() []*Command {
 	l.rwm.RLock()
-	var list = make([]*Command, len(l.commands))
-	for i, c := range l.commands {
-		list[i] = NewCommand(c.Name(), c.Aliases(), c.Usage())
-	}
+	commands := l.index.Commands()
 	l.rwm.RUnlock()
+	var list = make([]*Command, len(commands))
+	for i, c := range commands {
+		command := *c
+		list[i] = &command
+	}
 	return list
 }
 
 func (l *commandList) Get(i int) *Command {
 	l.rwm.RLock()
-	command := l.commands[i]
+	command := l.index.Get(i)
 	l.rwm.RUnlock()
 	return command
 }
 
 func (l *commandList) Find(command string) *Command {
 	l.rwm.RLock()
-	actualCommand := func() *Command {
-		i, ok := l.commandIndexes[command]
-		if !ok {
-			return nil
-		}
-		return l.commands[i]
-	}()
+	actualCommand, _ := l.index.Find(command)
 	l.rwm.RUnlock()
 	return actualCommand
 }
 
-func (l *commandList) Add(command *Command) {
-	aliases := append(command.Aliases(), command.Name())
-	if len(strings.TrimSpace(command.Name())) == 0 {
-		return
+// ValidateCommand checks the names and aliases of a command and of its subcommands,
+// and that they do not conflict with each other
+func ValidateCommand(command *Command) error {
+	names := map[string]bool{}
+	for _, name := range append([]string{command.Name()}, command.Aliases()...) {
+		if err := ValidateCommandName(name); err != nil {
+			return err
+		}
+		if names[name] {
+			return fmt.Errorf("command %s declares %q more than once", command.Name(), name)
+		}
+		names[name] = true
 	}
-	l.rwm.Lock()
-	func() {
-		l.commands = append(l.commands, command)
-		for _, alias := range aliases {
-			if len(strings.TrimSpace(alias)) == 0 {
-				return
+	subcommandNames := map[string]string{}
+	for _, subcommand := range command.Subcommands() {
+		if err := ValidateCommand(subcommand); err != nil {
+			return fmt.Errorf("subcommand of %s: %w", command.Name(), err)
+		}
+		for _, name := range append([]string{subcommand.Name()}, subcommand.Aliases()...) {
+			if other, ok := subcommandNames[name]; ok {
+				return fmt.Errorf("subcommand %s of %s conflicts with subcommand %s on %q", subcommand.Name(), command.Name(), other, name)
 			}
-			l.commandIndexes[alias] = len(l.commands) - 1
+			subcommandNames[name] = subcommand.Name()
 		}
-	}()
+	}
+	return nil
+}
+
+func (l *commandList) Add(command *Command) error {
+	if err := ValidateCommand(command); err != nil {
+		return err
+	}
+	l.rwm.Lock()
+	err := l.index.Add(command, namesOf(command))
 	l.rwm.Unlock()
+	return err
 }
 
-func NewCommandList(commands ...*Command) CommandList {
+// namesOf returns the name of a command followed by its aliases
+func namesOf(command *Command) []string {
+	return append([]string{command.Name()}, command.Aliases()...)
+}
+
+func newIndex(policy ConflictPolicy) *commandindex.Index[*Command] {
+	return commandindex.New(policy == LastWins, func(command *Command) string {
+		return command.Name()
+	})
+}
+
+// NewCommandListWithPolicy creates a CommandList resolving conflicts with policy.
+// It stops at the first command that cannot be added
+func NewCommandListWithPolicy(policy ConflictPolicy, commands ...*Command) (CommandList, error) {
 	ul := &commandList{
-		commands:       []*Command{},
-		commandIndexes: map[string]int{},
-		rwm:            &sync.RWMutex{},
+		rwm:    &sync.RWMutex{},
+		policy: policy,
+		index:  newIndex(policy),
+	}
+	for _, command := range commands {
+		if err := ul.Add(command); err != nil {
+			return ul, err
+		}
 	}
+	return ul, nil
+}
+
+// NewCommandList creates a CommandList rejecting conflicting commands. Commands that cannot be added are logged and skipped
+func NewCommandList(commands ...*Command) CommandList {
+	ul, _ := NewCommandListWithPolicy(RejectConflicts)
 	for _, command := range commands {
-		ul.Add(command)
+		if err := ul.Add(command); err != nil {
+			log.Println(err)
+		}
 	}
 	return ul
 }
@@ -0,0 +1,67 @@
+package domain
+
+import "testing"
+
+func TestCommandList_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		command *Command
+		wantErr bool
+	}{
+		{name: "valid command", command: NewCommand("forecast", []string{"f", "f2"}, "", WithSubcommands(NewCommand("today", nil, ""))), wantErr: false},
+		{name: "empty name", command: NewCommand("", nil, ""), wantErr: true},
+		{name: "uppercase name", command: NewCommand("Weather", nil, ""), wantErr: true},
+		{name: "empty alias", command: NewCommand("forecast", []string{""}, ""), wantErr: true},
+		{name: "name conflicts with alias", command: NewCommand("w", nil, ""), wantErr: true},
+		{name: "alias conflicts with name", command: NewCommand("meteo", []string{"quote"}, ""), wantErr: true},
+		{name: "duplicated alias", command: NewCommand("meteo", []string{"m", "m"}, ""), wantErr: true},
+		{name: "conflicting subcommands", command: NewCommand("meteo", nil, "", WithSubcommands(NewCommand("today", nil, ""), NewCommand("now", []string{"today"}, ""))), wantErr: true},
+		{name: "invalid subcommand", command: NewCommand("meteo", nil, "", WithSubcommands(NewCommand("to day", nil, ""))), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := NewCommandList(NewCommand("quote", nil, ""), NewCommand("weather", []string{"w"}, ""))
+			if err := list.Add(tt.command); (err != nil) != tt.wantErr {
+				t.Errorf("Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandList_LastWins(t *testing.T) {
+	list, err := NewCommandListWithPolicy(LastWins, NewCommand("quote", []string{"q"}, ""), NewCommand("weather", []string{"w", "meteo"}, ""))
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if err := list.Add(NewCommand("forecast", []string{"meteo", "quote"}, "")); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if c := list.Find("q"); c != nil {
+		t.Errorf("expected quote to be removed with its aliases got %v", c.Name())
+	}
+	if c := list.Find("w"); c == nil || c.Name() != "weather" {
+		t.Errorf("expected weather to keep its other aliases got %v", c)
+	}
+	if c := list.Find("meteo"); c == nil || c.Name() != "forecast" {
+		t.Errorf("expected meteo to be taken over got %v", c)
+	}
+	if len(list.All()) != 2 {
+		t.Errorf("expected %v commands got %v", 2, len(list.All()))
+	}
+}
+
+func TestCommandList_Append(t *testing.T) {
+	list := NewCommandList(NewCommand("quote", nil, ""))
+	if err := list.Append(NewCommandList(NewCommand("weather", nil, ""), NewCommand("q", []string{"quote"}, ""))); err == nil {
+		t.Fatalf("expected conflicting command to be rejected")
+	}
+	if len(list.All()) != 1 || list.Find("weather") != nil {
+		t.Errorf("expected a failed Append not to add any command got %v", list.All())
+	}
+	if err := list.Append(NewCommandList(NewCommand("weather", nil, ""), NewCommand("forecast", nil, ""))); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if len(list.All()) != 3 {
+		t.Errorf("expected %v commands got %v", 3, len(list.All()))
+	}
+}
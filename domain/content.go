@@ -0,0 +1,249 @@
+package domain
+
+import (
+	"strings"
+)
+
+// Style is a bitmask of text formatting
+type Style uint8
+
+const (
+	Bold Style = 1 << iota
+	Italic
+	Underline
+	Strikethrough
+	Monospace
+	PlainStyle Style = 0
+)
+
+func (s Style) Has(style Style) bool {
+	return s&style == style
+}
+
+// A Block is an element of a Content: Span, LineBreak, CodeBlock, Link, Attachment or Embed
+type Block interface {
+	block()
+}
+
+// A Span is inline text with a Style
+type Span struct {
+	Text  string
+	Style Style
+}
+
+type LineBreak struct{}
+
+// A CodeBlock is preformatted text, Language is an optional syntax highlighting hint
+type CodeBlock struct {
+	Language string
+	Code     string
+}
+
+// A Link is an inline hyperlink. Text may be empty in which case the URL is displayed
+type Link struct {
+	Text string
+	URL  string
+}
+
+// An Attachment is a file referenced by URL or uploaded from Data
+type Attachment struct {
+	Name     string
+	MimeType string
+	URL      string
+	Data     []byte
+}
+
+type EmbedField struct {
+	Name   string
+	Value  string
+	Inline bool
+}
+
+// An Embed is a card such as a link preview
+type Embed struct {
+	Title       string
+	Description string
+	URL         string
+	ImageURL    string
+	Fields      []EmbedField
+}
+
+func (Span) block()       {}
+func (LineBreak) block()  {}
+func (CodeBlock) block()  {}
+func (Link) block()       {}
+func (Attachment) block() {}
+func (Embed) block()      {}
+
+// Content is the structured body of a ClientMessage. Connectors render it natively or fall back to PlainText
+type Content struct {
+	blocks []Block
+}
+
+// Blocks returns a deep copy of the blocks of the content. A nil Content has none
+func (c *Content) Blocks() []Block {
+	if c == nil {
+		return nil
+	}
+	blocks := make([]Block, len(c.blocks))
+	for i, block := range c.blocks {
+		blocks[i] = copyBlock(block)
+	}
+	return blocks
+}
+
+// copyBlock copies the slices held by a block so that the copy shares nothing with it
+func copyBlock(block Block) Block {
+	switch b := block.(type) {
+	case Attachment:
+		return b.copy()
+	case Embed:
+		b.Fields = append([]EmbedField(nil), b.Fields...)
+		return b
+	default:
+		return block
+	}
+}
+
+func (a Attachment) copy() Attachment {
+	a.Data = append([]byte(nil), a.Data...)
+	return a
+}
+
+// Append adds blocks to the content and returns it to allow chaining
+func (c *Content) Append(blocks ...Block) *Content {
+	c.blocks = append(c.blocks, blocks...)
+	return c
+}
+
+func (c *Content) Text(text string, style Style) *Content {
+	return c.Append(Span{Text: text, Style: style})
+}
+
+func (c *Content) Line() *Content {
+	return c.Append(LineBreak{})
+}
+
+// Attachments returns a copy of the attachments of the content for connectors uploading them separately
+func (c *Content) Attachments() []Attachment {
+	if c == nil {
+		return nil
+	}
+	var attachments []Attachment
+	for _, block := range c.blocks {
+		if attachment, ok := block.(Attachment); ok {
+			attachments = append(attachments, attachment.copy())
+		}
+	}
+	return attachments
+}
+
+func NewContent(blocks ...Block) *Content {
+	return &Content{blocks: blocks}
+}
+
+// PlainText returns the text displayed for the link, its URL or its text followed by the URL
+func (l Link) PlainText() string {
+	if len(l.Text) == 0 || l.Text == l.URL {
+		return l.URL
+	}
+	return l.Text + " (" + l.URL + ")"
+}
+
+// PlainText returns the text displayed for the attachment, its URL or its name
+func (a Attachment) PlainText() string {
+	switch {
+	case len(a.URL) > 0:
+		return a.URL
+	case len(a.Name) > 0:
+		return "[" + a.Name + "]"
+	default:
+		return "[attachment]"
+	}
+}
+
+// A Formatter formats each kind of block of a Content for a text based platform
+type Formatter interface {
+	Span(span Span) string
+	CodeBlock(block CodeBlock) string
+	Link(link Link) string
+	Attachment(attachment Attachment) string
+	Embed(embed Embed) string
+}
+
+// Format lays out content with f. Code blocks and non empty embeds start on their own line and end with a line break.
+// A nil Content is empty
+func Format(content *Content, f Formatter) string {
+	if content == nil {
+		return ""
+	}
+	var sb strings.Builder
+	newLine := func() {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	for _, block := range content.blocks {
+		switch b := block.(type) {
+		case Span:
+			sb.WriteString(f.Span(b))
+		case LineBreak:
+			sb.WriteString("\n")
+		case CodeBlock:
+			newLine()
+			sb.WriteString(f.CodeBlock(b) + "\n")
+		case Link:
+			sb.WriteString(f.Link(b))
+		case Attachment:
+			sb.WriteString(f.Attachment(b))
+		case Embed:
+			if formatted := f.Embed(b); len(formatted) > 0 {
+				newLine()
+				sb.WriteString(formatted + "\n")
+			}
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+type plainFormatter struct{}
+
+var _ Formatter = plainFormatter{}
+
+func (plainFormatter) Span(span Span) string {
+	return span.Text
+}
+
+func (plainFormatter) CodeBlock(block CodeBlock) string {
+	return strings.TrimSuffix(block.Code, "\n")
+}
+
+func (plainFormatter) Link(link Link) string {
+	return link.PlainText()
+}
+
+func (plainFormatter) Attachment(attachment Attachment) string {
+	return attachment.PlainText()
+}
+
+func (plainFormatter) Embed(embed Embed) string {
+	var lines []string
+	for _, line := range []string{embed.Title, embed.Description} {
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	for _, field := range embed.Fields {
+		lines = append(lines, field.Name+": "+field.Value)
+	}
+	if len(embed.URL) > 0 {
+		lines = append(lines, embed.URL)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PlainText renders a Content without formatting, for platforms supporting none. A nil Content is empty.
+// Links and attachments are displayed with their URL, code blocks and embeds on their own lines
+func PlainText(content *Content) string {
+	return Format(content, plainFormatter{})
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content *Content
+		want    string
+	}{
+		{name: "nil", content: nil, want: ""},
+		{name: "spans", content: NewContent().Text("hello ", Bold).Text("world", Italic|Underline), want: "hello world"},
+		{name: "link", content: NewContent(Link{Text: "docs", URL: "https://example.com"}, Span{Text: " "}, Link{URL: "https://example.org"}), want: "docs (https://example.com) https://example.org"},
+		{name: "code block", content: NewContent(Span{Text: "result:"}, CodeBlock{Language: "go", Code: "x := 1\n"}, Span{Text: "done"}), want: "result:\nx := 1\ndone"},
+		{name: "attachments", content: NewContent(Attachment{URL: "https://example.com/a.png"}, LineBreak{}, Attachment{Name: "log.txt", Data: []byte("log")}), want: "https://example.com/a.png\n[log.txt]"},
+		{name: "embed", content: NewContent(Span{Text: "see"}, Embed{Title: "Title", Description: "Description", URL: "https://example.com", Fields: []EmbedField{{Name: "stars", Value: "5"}}}), want: "see\nTitle\nDescription\nstars: 5\nhttps://example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(tt.content); got != tt.want {
+				t.Errorf("expected %q got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewRichClientMessage(t *testing.T) {
+	content := NewContent().Text("bold", Bold).Append(Attachment{Name: "a.txt", Data: []byte("a")})
+	message := NewRichClientMessage(content, nil, false)
+	if message.Message() != "bold[a.txt]" {
+		t.Errorf("expected %v got %v", "bold[a.txt]", message.Message())
+	}
+	if len(message.Content().Attachments()) != 1 {
+		t.Errorf("expected 1 attachment got %v", len(message.Content().Attachments()))
+	}
+	if blocks := NewClientMessage("plain", nil, false).Content().Blocks(); len(blocks) != 1 || blocks[0] != (Span{Text: "plain"}) {
+		t.Errorf("expected a single plain span got %v", blocks)
+	}
+	content.Text(" more", PlainStyle)
+	message.Content().Text(" more", PlainStyle)
+	if PlainText(message.Content()) != message.Message() {
+		t.Errorf("expected content to match %q got %q", message.Message(), PlainText(message.Content()))
+	}
+	if empty := NewRichClientMessage(nil, nil, false); empty.Message() != "" || len(empty.Content().Blocks()) != 0 {
+		t.Errorf("expected nil content to be empty got %q", empty.Message())
+	}
+}
+
+func TestContent_Blocks(t *testing.T) {
+	var empty *Content
+	if len(empty.Blocks()) != 0 || len(empty.Attachments()) != 0 {
+		t.Errorf("expected a nil content to be empty")
+	}
+	content := NewContent(Attachment{Name: "a.txt", Data: []byte("a")}, Embed{Fields: []EmbedField{{Name: "stars", Value: "5"}}})
+	content.Blocks()[1].(Embed).Fields[0].Value = "1"
+	content.Attachments()[0].Data[0] = 'b'
+	if embed := content.Blocks()[1].(Embed); embed.Fields[0].Value != "5" {
+		t.Errorf("expected embed fields to be copied got %v", embed.Fields[0].Value)
+	}
+	if data := content.Attachments()[0].Data; string(data) != "a" {
+		t.Errorf("expected attachment data to be copied got %s", data)
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"fmt"
+	"github.com/segmentio/ksuid"
 	"time"
 )
 
@@ -9,7 +10,79 @@ type ServerMessage interface {
 	Timestamp() time.Time
 }
 
+// messageMetadata identifies a message, the channel it was sent to and the message or thread it belongs to
+// on platforms supporting replies and threads
+type messageMetadata struct {
+	id       string
+	replyTo  string
+	threadId string
+	channel  *Channel
+}
+
+func (m *messageMetadata) Id() string {
+	return m.id
+}
+
+// ReplyTo returns the id of the message this message answers
+func (m *messageMetadata) ReplyTo() string {
+	return m.replyTo
+}
+
+func (m *messageMetadata) ThreadId() string {
+	return m.threadId
+}
+
+// Channel returns the channel of the message, nil for private messages and connectors without channels
+func (m *messageMetadata) Channel() *Channel {
+	return m.channel
+}
+
+// MessageOptions returns the options reproducing the metadata of the message
+func (m *messageMetadata) MessageOptions() []MessageOption {
+	return []MessageOption{WithId(m.id), WithReplyTo(m.replyTo), WithThreadId(m.threadId), WithChannel(m.channel)}
+}
+
+type MessageOption func(metadata *messageMetadata)
+
+// WithId sets the id given to a message by the platform.
+// ChatMessage and CommandMessage get a generated id otherwise
+func WithId(id string) MessageOption {
+	return func(metadata *messageMetadata) {
+		metadata.id = id
+	}
+}
+
+func WithReplyTo(id string) MessageOption {
+	return func(metadata *messageMetadata) {
+		metadata.replyTo = id
+	}
+}
+
+func WithThreadId(threadId string) MessageOption {
+	return func(metadata *messageMetadata) {
+		metadata.threadId = threadId
+	}
+}
+
+func WithChannel(channel *Channel) MessageOption {
+	return func(metadata *messageMetadata) {
+		metadata.channel = channel
+	}
+}
+
+func newMessageMetadata(generateId bool, options []MessageOption) messageMetadata {
+	var metadata messageMetadata
+	for _, option := range options {
+		option(&metadata)
+	}
+	if generateId && len(metadata.id) == 0 {
+		metadata.id = ksuid.New().String()
+	}
+	return metadata
+}
+
 type ChatMessage struct {
+	messageMetadata
 	recipients            []*User
 	timestamp             time.Time
 	message               string
@@ -35,8 +108,8 @@ func (s *ChatMessage) Timestamp() time.Time {
 	return s.timestamp
 }
 
-func NewChatMessage(message string, sender *User, recipients []*User, mentionsConnectorUser bool, private bool, timestamp time.Time, incoming bool) *ChatMessage {
-	return &ChatMessage{message: message, sender: sender, mentionsConnectorUser: mentionsConnectorUser, recipients: recipients, private: private, timestamp: timestamp, incoming: incoming}
+func NewChatMessage(message string, sender *User, recipients []*User, mentionsConnectorUser bool, private bool, timestamp time.Time, incoming bool, options ...MessageOption) *ChatMessage {
+	return &ChatMessage{messageMetadata: newMessageMetadata(true, options), message: message, sender: sender, mentionsConnectorUser: mentionsConnectorUser, recipients: recipients, private: private, timestamp: timestamp, incoming: incoming}
 }
 
 func (s *ChatMessage) Message() string {
@@ -62,6 +135,7 @@ type UserEvent struct {
 	user      *User
 	eventType UserEventType
 	timestamp time.Time
+	channel   *Channel
 }
 
 func (u *UserEvent) User() *User {
@@ -76,11 +150,18 @@ func (u *UserEvent) Timestamp() time.Time {
 	return u.timestamp
 }
 
-func NewUserEvent(user *User, eventType UserEventType, timestamp time.Time) *UserEvent {
-	return &UserEvent{user: user, eventType: eventType, timestamp: timestamp}
+// Channel returns the channel the user joined or left, nil if the platform has no channels
+func (u *UserEvent) Channel() *Channel {
+	return u.channel
+}
+
+// NewUserEvent creates a UserEvent. Events are not messages, only WithChannel applies to them
+func NewUserEvent(user *User, eventType UserEventType, timestamp time.Time, options ...MessageOption) *UserEvent {
+	return &UserEvent{user: user, eventType: eventType, timestamp: timestamp, channel: newMessageMetadata(false, options).channel}
 }
 
 type CommandMessage struct {
+	messageMetadata
 	command   string
 	args      []string
 	argString string
@@ -89,16 +170,17 @@ type CommandMessage struct {
 	timestamp time.Time
 }
 
-func NewCommandMessage(command string, args []string, argString string, sender *User, private bool, timestamp time.Time) *CommandMessage {
-	return &CommandMessage{command: command, args: args, argString: argString, sender: sender, private: private, timestamp: timestamp}
+func NewCommandMessage(command string, args []string, argString string, sender *User, private bool, timestamp time.Time, options ...MessageOption) *CommandMessage {
+	return &CommandMessage{messageMetadata: newMessageMetadata(true, options), command: command, args: args, argString: argString, sender: sender, private: private, timestamp: timestamp}
 }
 
 func (c *CommandMessage) ToChatMessage() *ChatMessage {
 	return &ChatMessage{
-		message:   fmt.Sprintf("%s %s", c.command, c.argString),
-		sender:    c.sender,
-		private:   c.private,
-		timestamp: c.timestamp,
+		messageMetadata: c.messageMetadata,
+		message:         fmt.Sprintf("%s %s", c.command, c.argString),
+		sender:          c.sender,
+		private:         c.private,
+		timestamp:       c.timestamp,
 	}
 }
 
@@ -126,8 +208,17 @@ func (c *CommandMessage) Timestamp() time.Time {
 	return c.timestamp
 }
 
+// Reply creates a ClientMessage answering the CommandMessage in its channel, and in its thread if it has one
+func (c *CommandMessage) Reply(message string) *ClientMessage {
+	return NewClientMessage(message, c.sender, c.private, WithReplyTo(c.id), WithThreadId(c.threadId), WithChannel(c.channel))
+}
+
+// A ClientMessage is sent by the bot. Its ReplyTo and ThreadId, when set, let connectors thread it on platforms supporting it.
+// Connectors supporting formatting render its Content, the others send its Message
 type ClientMessage struct {
+	messageMetadata
 	message   string
+	content   *Content
 	recipient *User
 	emote     bool
 	private   bool
@@ -141,6 +232,15 @@ func (c *ClientMessage) Message() string {
 	return c.message
 }
 
+// Content returns a copy of the rich content of the message, a single plain Span for messages created from text.
+// Appending to it does not change the message
+func (c *ClientMessage) Content() *Content {
+	if c.content == nil {
+		return NewContent(Span{Text: c.message})
+	}
+	return NewContent(c.content.Blocks()...)
+}
+
 func (c *ClientMessage) Recipient() *User {
 	return c.recipient
 }
@@ -149,12 +249,24 @@ func (c *ClientMessage) Private() bool {
 	return c.private
 }
 
-func NewClientMessage(message string, recipient *User, private bool) *ClientMessage {
-	return &ClientMessage{message: message, recipient: recipient, private: private}
+func NewClientMessage(message string, recipient *User, private bool, options ...MessageOption) *ClientMessage {
+	return &ClientMessage{messageMetadata: newMessageMetadata(false, options), message: message, recipient: recipient, private: private}
+}
+
+// NewRichClientMessage creates a ClientMessage whose Message is the PlainText fallback of content.
+// The message keeps a copy of content, a nil content is empty
+func NewRichClientMessage(content *Content, recipient *User, private bool, options ...MessageOption) *ClientMessage {
+	copied := NewContent()
+	if content != nil {
+		copied = NewContent(content.Blocks()...)
+	}
+	return &ClientMessage{messageMetadata: newMessageMetadata(false, options), message: PlainText(copied), content: copied, recipient: recipient, private: private}
 }
 
-func NewEmote(message string) *ClientMessage {
-	return &ClientMessage{message: message, emote: true}
+var _ = NewRichClientMessage
+
+func NewEmote(message string, options ...MessageOption) *ClientMessage {
+	return &ClientMessage{messageMetadata: newMessageMetadata(false, options), message: message, emote: true}
 }
 
 type RegistrationMessage struct {
@@ -173,6 +285,7 @@ type ConfirmationMessage struct {
 	currentUser *User
 	trigger     string
 	users       UserList
+	channels    []*Channel
 }
 
 func (c *ConfirmationMessage) CurrentUser() *User {
@@ -187,6 +300,26 @@ func (c *ConfirmationMessage) Users() UserList {
 	return c.users.Copy()
 }
 
-func NewConfirmationMessage(currentUser *User, trigger string, users []*User) *ConfirmationMessage {
-	return &ConfirmationMessage{currentUser: currentUser, trigger: trigger, users: ImmutableUserList(NewUserList(users...))}
+// Channels returns the channels joined by the connector along with their online users
+func (c *ConfirmationMessage) Channels() []*Channel {
+	channels := make([]*Channel, len(c.channels))
+	for i, channel := range c.channels {
+		channels[i] = &Channel{id: channel.id, name: channel.name, users: channel.users.Copy()}
+	}
+	return channels
+}
+
+// Channel returns the joined channel with the given id, nil if there is none
+func (c *ConfirmationMessage) Channel(id string) *Channel {
+	for _, channel := range c.Channels() {
+		if channel.id == id {
+			return channel
+		}
+	}
+	return nil
+}
+
+// NewConfirmationMessage creates a ConfirmationMessage. Users are all the online users, channels hold the online users of each joined channel
+func NewConfirmationMessage(currentUser *User, trigger string, users []*User, channels ...*Channel) *ConfirmationMessage {
+	return &ConfirmationMessage{currentUser: currentUser, trigger: trigger, users: ImmutableUserList(NewUserList(users...)), channels: channels}
 }
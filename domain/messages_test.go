@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCommandMessage_Reply(t *testing.T) {
+	sender := NewUser("user", "id", RegularUser)
+	message := NewCommandMessage("quote", nil, "", sender, true, time.Now(), WithId("42"), WithThreadId("thread"))
+	reply := message.Reply("hello")
+	if reply.ReplyTo() != "42" || reply.ThreadId() != "thread" {
+		t.Errorf("expected reply to 42 in thread got %q in %q", reply.ReplyTo(), reply.ThreadId())
+	}
+	if reply.Recipient() != sender || !reply.Private() {
+		t.Errorf("expected private reply to the sender")
+	}
+	if chatMessage := message.ToChatMessage(); chatMessage.Id() != "42" {
+		t.Errorf("expected %v got %v", "42", chatMessage.Id())
+	}
+}
+
+func TestNewChatMessage_Id(t *testing.T) {
+	first := NewChatMessage("hello", nil, nil, false, false, time.Now(), true)
+	second := NewChatMessage("hello", nil, nil, false, false, time.Now(), true)
+	if len(first.Id()) == 0 || first.Id() == second.Id() {
+		t.Errorf("expected unique generated ids got %q and %q", first.Id(), second.Id())
+	}
+}
+
+func TestChannel_Propagation(t *testing.T) {
+	channel := NewChannel("c1", "general")
+	message := NewCommandMessage("quote", nil, "", NewUser("user", "id", RegularUser), false, time.Now(), WithChannel(channel))
+	if reply := message.Reply("hello"); !channel.Is(reply.Channel()) {
+		t.Errorf("expected reply in channel %v got %v", channel.Id(), reply.Channel())
+	}
+	if chatMessage := message.ToChatMessage(); !channel.Is(chatMessage.Channel()) {
+		t.Errorf("expected chat message in channel %v got %v", channel.Id(), chatMessage.Channel())
+	}
+}
+
+func TestConfirmationMessage_Channel(t *testing.T) {
+	user := NewUser("user", "id", RegularUser)
+	confirmation := NewConfirmationMessage(nil, "!", []*User{user}, NewChannel("c1", "general", user), NewChannel("c2", "random"))
+	if channel := confirmation.Channel("c1"); channel == nil || channel.Users().Find("user") == nil {
+		t.Errorf("expected user online in c1")
+	}
+	if channel := confirmation.Channel("c2"); channel == nil || channel.Users().Find("user") != nil {
+		t.Errorf("expected no user online in c2")
+	}
+	if confirmation.Channel("c3") != nil {
+		t.Errorf("expected unknown channel to be nil")
+	}
+}
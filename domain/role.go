@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// A RoleRegistry maps each UserRole to the Permission it grants.
+// A role grants its own permission bits and those of the roles it inherits from
+type RoleRegistry interface {
+	Define(role UserRole, permission Permission, inherits ...UserRole) error
+	Remove(role UserRole)
+	Permissions(role UserRole) Permission
+	Roles() []UserRole
+}
+
+var _ RoleRegistry = (*roleRegistry)(nil)
+
+type roleDefinition struct {
+	permission Permission
+	inherits   []UserRole
+}
+
+type roleRegistry struct {
+	rwm   *sync.RWMutex
+	roles map[UserRole]roleDefinition
+}
+
+func (r *roleRegistry) inheritsFrom(role UserRole, ancestor UserRole, visited map[UserRole]bool) bool {
+	if role == ancestor {
+		return true
+	}
+	if visited[role] {
+		return false
+	}
+	visited[role] = true
+	for _, parent := range r.roles[role].inherits {
+		if r.inheritsFrom(parent, ancestor, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// Define adds or replaces a role. Inherited roles must already be defined and cannot inherit from the defined role
+func (r *roleRegistry) Define(role UserRole, permission Permission, inherits ...UserRole) error {
+	if len(role) == 0 {
+		return fmt.Errorf("role name cannot be empty")
+	}
+	r.rwm.Lock()
+	err := func() error {
+		for _, parent := range inherits {
+			if _, ok := r.roles[parent]; !ok {
+				return fmt.Errorf("role %s inherits from unknown role %s", role, parent)
+			}
+			if r.inheritsFrom(parent, role, map[UserRole]bool{}) {
+				return fmt.Errorf("role %s cannot inherit from %s: inheritance cycle", role, parent)
+			}
+		}
+		r.roles[role] = roleDefinition{permission: permission, inherits: append([]UserRole(nil), inherits...)}
+		return nil
+	}()
+	r.rwm.Unlock()
+	return err
+}
+
+// Remove deletes a role. Roles inheriting from it stop inheriting from it and lose the permissions it granted,
+// even if a role with the same name is defined again
+func (r *roleRegistry) Remove(role UserRole) {
+	r.rwm.Lock()
+	delete(r.roles, role)
+	for name, definition := range r.roles {
+		inherits := make([]UserRole, 0, len(definition.inherits))
+		for _, parent := range definition.inherits {
+			if parent != role {
+				inherits = append(inherits, parent)
+			}
+		}
+		if len(inherits) != len(definition.inherits) {
+			definition.inherits = inherits
+			r.roles[name] = definition
+		}
+	}
+	r.rwm.Unlock()
+}
+
+func (r *roleRegistry) permissions(role UserRole, visited map[UserRole]bool) Permission {
+	definition, ok := r.roles[role]
+	if !ok || visited[role] {
+		return IsUnknown
+	}
+	visited[role] = true
+	permission := definition.permission
+	for _, parent := range definition.inherits {
+		permission |= r.permissions(parent, visited)
+	}
+	return permission
+}
+
+// Permissions returns the Permission granted by a role, IsUnknown if it is not defined
+func (r *roleRegistry) Permissions(role UserRole) Permission {
+	r.rwm.RLock()
+	permission := r.permissions(role, map[UserRole]bool{})
+	r.rwm.RUnlock()
+	return permission
+}
+
+func (r *roleRegistry) Roles() []UserRole {
+	r.rwm.RLock()
+	roles := make([]UserRole, 0, len(r.roles))
+	for role := range r.roles {
+		roles = append(roles, role)
+	}
+	r.rwm.RUnlock()
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i] < roles[j]
+	})
+	return roles
+}
+
+// NewRoleRegistry creates a RoleRegistry defining RegularUser, Moderator and Admin
+func NewRoleRegistry() RoleRegistry {
+	registry := &roleRegistry{
+		rwm:   &sync.RWMutex{},
+		roles: map[UserRole]roleDefinition{},
+	}
+	_ = registry.Define(RegularUser, IsUnknown)
+	_ = registry.Define(Moderator, IsModerator, RegularUser)
+	_ = registry.Define(Admin, IsAdmin, Moderator)
+	return registry
+}
+
+var roles = NewRoleRegistry()
+
+// GetRoleRegistry returns the RoleRegistry used by User.Permissions
+func GetRoleRegistry() RoleRegistry {
+	return roles
+}
+
+var _ = GetRoleRegistry
+
+// DefineRole defines a role in the registry used by User.Permissions
+func DefineRole(role UserRole, permission Permission, inherits ...UserRole) error {
+	return roles.Define(role, permission, inherits...)
+}
+
+var _ = DefineRole
@@ -0,0 +1,70 @@
+package domain
+
+import "testing"
+
+func TestRoleRegistry_Permissions(t *testing.T) {
+	const NeedTrusted Permission = 8
+	registry := NewRoleRegistry()
+	if err := registry.Define("Trusted", NeedTrusted, RegularUser); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if err := registry.Define("Owner", NeedVerified, Admin, "Trusted"); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	tests := []struct {
+		name string
+		role UserRole
+		want Permission
+	}{
+		{name: "RegularUser", role: RegularUser, want: IsUnknown},
+		{name: "Moderator", role: Moderator, want: IsModerator},
+		{name: "Admin", role: Admin, want: IsAdmin},
+		{name: "Trusted", role: "Trusted", want: NeedTrusted},
+		{name: "Owner inherits Admin and Trusted", role: "Owner", want: IsAdmin | NeedTrusted},
+		{name: "Undefined", role: "Bot", want: IsUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.Permissions(tt.role); got != tt.want {
+				t.Errorf("Permissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleRegistry_Define(t *testing.T) {
+	registry := NewRoleRegistry()
+	if err := registry.Define("Bot", IsVerified, "Unknown"); err == nil {
+		t.Errorf("expected error for unknown parent role")
+	}
+	if err := registry.Define(RegularUser, IsUnknown, Admin); err == nil {
+		t.Errorf("expected error for inheritance cycle")
+	}
+}
+
+func TestRoleRegistry_Remove(t *testing.T) {
+	const NeedTrusted Permission = 8
+	registry := NewRoleRegistry()
+	if err := registry.Define("Trusted", NeedTrusted); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if err := registry.Define("Owner", NeedVerified, "Trusted"); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	registry.Remove("Trusted")
+	if got := registry.Permissions("Owner"); got != NeedVerified {
+		t.Errorf("Permissions() = %v, want %v", got, NeedVerified)
+	}
+	if err := registry.Define("Trusted", NeedTrusted); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if got := registry.Permissions("Owner"); got != NeedVerified {
+		t.Errorf("expected Owner not to inherit from the redefined role got %v", got)
+	}
+}
+
+func TestUser_Permissions(t *testing.T) {
+	if got := NewUser("admin", "id", Admin).Permissions(); !got.Has(NeedAdmin) {
+		t.Errorf("Permissions() = %v, want %v", got, IsAdmin)
+	}
+}
@@ -61,3 +61,8 @@ func (u *User) Role() UserRole {
 func (u *User) JoinedAt() *time.Time {
 	return u.joinedAt
 }
+
+// Permissions returns the Permission granted to the user's role by the registry returned by GetRoleRegistry
+func (u *User) Permissions() Permission {
+	return roles.Permissions(u.role)
+}
@@ -1,6 +1,6 @@
 module github.com/raf924/connector-sdk
 
-go 1.17
+go 1.18
 
 require (
 	github.com/segmentio/ksuid v1.0.4
@@ -0,0 +1,120 @@
+// Package commandindex holds the commands of the command lists of the SDK by name and alias
+package commandindex
+
+import (
+	"fmt"
+)
+
+// Index holds commands in the order they were added along with the position of their names and aliases.
+// It is shared by the command lists of the SDK, which guard it with their own lock
+type Index[T any] struct {
+	// lastWins gives conflicting names to the added command instead of rejecting it
+	lastWins bool
+	name     func(command T) string
+	commands []T
+	indexes  map[string]int
+}
+
+// New creates an Index. name returns the name of a command, used to tell names from aliases.
+// With lastWins, a command losing its name to an added one is removed, a command losing an alias keeps its other names
+func New[T any](lastWins bool, name func(command T) string) *Index[T] {
+	return &Index[T]{
+		lastWins: lastWins,
+		name:     name,
+		indexes:  map[string]int{},
+	}
+}
+
+// Commands returns a copy of the commands in the order they were added
+func (c *Index[T]) Commands() []T {
+	return append([]T(nil), c.commands...)
+}
+
+func (c *Index[T]) Get(i int) T {
+	return c.commands[i]
+}
+
+// Find returns the command matching a name or an alias
+func (c *Index[T]) Find(name string) (T, bool) {
+	i, ok := c.indexes[name]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return c.commands[i], true
+}
+
+// remove deletes the command at index i along with its names
+func (c *Index[T]) remove(i int) {
+	c.commands = append(c.commands[:i], c.commands[i+1:]...)
+	for name, j := range c.indexes {
+		if j == i {
+			delete(c.indexes, name)
+		} else if j > i {
+			c.indexes[name] = j - 1
+		}
+	}
+}
+
+// Add appends a command known by names, its name followed by its aliases
+func (c *Index[T]) Add(command T, names []string) error {
+	for _, name := range names {
+		i, ok := c.indexes[name]
+		if !ok {
+			continue
+		}
+		if !c.lastWins || interface{}(c.commands[i]) == interface{}(command) {
+			return fmt.Errorf("%q of command %s conflicts with command %s", name, names[0], c.name(c.commands[i]))
+		}
+	}
+	for _, name := range names {
+		i, ok := c.indexes[name]
+		if !ok {
+			continue
+		}
+		if c.name(c.commands[i]) == name {
+			c.remove(i)
+		} else {
+			delete(c.indexes, name)
+		}
+	}
+	c.commands = append(c.commands, command)
+	for _, name := range names {
+		c.indexes[name] = len(c.commands) - 1
+	}
+	return nil
+}
+
+// Remove deletes the command matching a name or an alias
+func (c *Index[T]) Remove(name string) error {
+	i, ok := c.indexes[name]
+	if !ok {
+		return fmt.Errorf("unknown command %s", name)
+	}
+	c.remove(i)
+	return nil
+}
+
+// Replace swaps the command whose name, not alias, is the name of replacement, keeping its position.
+// It fails if the replacement conflicts with another command, even with lastWins
+func (c *Index[T]) Replace(replacement T, names []string) error {
+	i, ok := c.indexes[names[0]]
+	if !ok || c.name(c.commands[i]) != names[0] {
+		return fmt.Errorf("unknown command %s", names[0])
+	}
+	for _, name := range names {
+		if j, ok := c.indexes[name]; ok && j != i {
+			return fmt.Errorf("%q of command %s conflicts with command %s", name, names[0], c.name(c.commands[j]))
+		}
+	}
+	for name, j := range c.indexes {
+		if j == i {
+			delete(c.indexes, name)
+		}
+	}
+	c.commands[i] = replacement
+	for _, name := range names {
+		c.indexes[name] = i
+	}
+	return nil
+}
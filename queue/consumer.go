@@ -1,26 +1,50 @@
 package queue
 
-type Consumer interface {
-	Consume() (interface{}, error)
+import (
+	"context"
+	"errors"
+)
+
+var ErrCancelled = errors.New("consumer cancelled")
+
+type TypedConsumer[T any] interface {
+	// Consume waits for the next value. It returns ErrCancelled once the consumer is cancelled, even while waiting
+	Consume() (T, error)
+	// ConsumeContext waits for the next value until ctx is done, in which case it returns the error of ctx
+	ConsumeContext(ctx context.Context) (T, error)
+	// TryConsume returns the next value if there is one without waiting
+	TryConsume() (T, bool, error)
 	Cancel()
 }
 
-type consumer struct {
+// Consumer is the untyped TypedConsumer
+type Consumer = TypedConsumer[interface{}]
+
+type consumer[T any] struct {
 	id string
-	q  consumable
+	q  consumable[T]
+	// buffer stays with the consumer once it is cancelled or evicted, its error telling why
+	buffer *linkedBuffer[T]
 }
 
-var _ Consumer = (*consumer)(nil)
+var _ Consumer = (*consumer[interface{}])(nil)
+
+func (c *consumer[T]) Consume() (T, error) {
+	return c.buffer.consume(context.Background())
+}
+
+func (c *consumer[T]) ConsumeContext(ctx context.Context) (T, error) {
+	return c.buffer.consume(ctx)
+}
 
-func (c *consumer) Consume() (interface{}, error) {
-	return c.q.consume(c.id)
+func (c *consumer[T]) TryConsume() (T, bool, error) {
+	return c.buffer.tryConsume()
 }
 
-func (c *consumer) Cancel() {
+func (c *consumer[T]) Cancel() {
 	c.q.cancel(c.id)
 }
 
-type consumable interface {
-	consume(id string) (interface{}, error)
+type consumable[T any] interface {
 	cancel(id string)
 }
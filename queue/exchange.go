@@ -1,16 +1,20 @@
 package queue
 
-type Exchange interface {
-	Producer
-	Consumer
+type TypedExchange[T any] interface {
+	TypedProducer[T]
+	TypedConsumer[T]
 }
 
-type exchange struct {
-	Producer
-	Consumer
+// Exchange is the untyped TypedExchange
+type Exchange = TypedExchange[interface{}]
+
+type exchange[T any] struct {
+	TypedProducer[T]
+	TypedConsumer[T]
 }
 
-func NewExchange(producerQueue, consumerQueue Queue) (Exchange, error) {
+// NewTypedExchange produces to producerQueue and consumes from consumerQueue
+func NewTypedExchange[T any](producerQueue, consumerQueue TypedQueue[T]) (TypedExchange[T], error) {
 	producer, err := producerQueue.NewProducer()
 	if err != nil {
 		return nil, err
@@ -19,10 +23,14 @@ func NewExchange(producerQueue, consumerQueue Queue) (Exchange, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &exchange{
-		Producer: producer,
-		Consumer: consumer,
+	return &exchange[T]{
+		TypedProducer: producer,
+		TypedConsumer: consumer,
 	}, nil
 }
 
+func NewExchange(producerQueue, consumerQueue Queue) (Exchange, error) {
+	return NewTypedExchange[interface{}](producerQueue, consumerQueue)
+}
+
 var _ = NewExchange
@@ -0,0 +1,156 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"github.com/segmentio/ksuid"
+	"sort"
+	"sync/atomic"
+)
+
+// ErrNoMember is returned by Produce when every member of a group was cancelled while the value was delivered to it.
+// The value is dropped for that group
+var ErrNoMember = errors.New("consumer group has no member left")
+
+// GroupStrategy decides which member of a consumer group receives a value
+type GroupStrategy int
+
+const (
+	// RoundRobin delivers values to the members of a group in turn
+	RoundRobin GroupStrategy = iota
+	// LeastLoaded delivers a value to the member with the fewest buffered values
+	LeastLoaded
+)
+
+func (g GroupStrategy) String() string {
+	switch g {
+	case RoundRobin:
+		return "round robin"
+	case LeastLoaded:
+		return "least loaded"
+	default:
+		return fmt.Sprintf("GroupStrategy(%d)", int(g))
+	}
+}
+
+type consumerGroup struct {
+	strategy GroupStrategy
+	// members are the consumer ids in joining order. They are guarded by the queue lock
+	members []string
+	next    uint32
+}
+
+// groupBuffers is a snapshot of the buffers of a group's members
+type groupBuffers[T any] struct {
+	group   *consumerGroup
+	members []consumerBuffer[T]
+}
+
+// candidates orders the members by preference according to the group's strategy
+func (g groupBuffers[T]) candidates() []consumerBuffer[T] {
+	candidates := make([]consumerBuffer[T], len(g.members))
+	switch g.group.strategy {
+	case LeastLoaded:
+		copy(candidates, g.members)
+		sizes := make(map[string]int, len(candidates))
+		for _, candidate := range candidates {
+			sizes[candidate.id] = candidate.buffer.len()
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return sizes[candidates[i].id] < sizes[candidates[j].id]
+		})
+	default:
+		start := int(atomic.AddUint32(&g.group.next, 1)-1) % len(g.members)
+		copy(candidates, g.members[start:])
+		copy(candidates[len(g.members)-start:], g.members[:start])
+	}
+	return candidates
+}
+
+// deliver pushes value to a single member, trying the next candidate if a member was cancelled meanwhile.
+// Values handed over by a cancelled member are inserted in production order rather than appended.
+// It returns ErrNoMember if every member was cancelled, in which case the value is dropped
+func (g groupBuffers[T]) deliver(qv *bufferValue[T], handOver bool) error {
+	for _, candidate := range g.candidates() {
+		var err error
+		if handOver {
+			err = candidate.buffer.insert(qv)
+		} else {
+			_, err = candidate.buffer.push(qv)
+		}
+		if err != errClosed {
+			return err
+		}
+	}
+	return ErrNoMember
+}
+
+// NewGroupConsumer adds a consumer to a named group, creating the group if needed.
+// Each value is delivered to a single member of each group, chosen by the strategy the group was created with,
+// while consumers outside of groups still receive every value. The values buffered by a cancelled member are handed to the others in production order.
+// The group is deleted when its last member is cancelled
+func (q *queue[T]) NewGroupConsumer(group string, strategy GroupStrategy) (TypedConsumer[T], error) {
+	if strategy < RoundRobin || strategy > LeastLoaded {
+		return nil, fmt.Errorf("unknown group strategy %v", strategy)
+	}
+	id := ksuid.New().String()
+	buffer := newLinkedBuffer[T](0, Block)
+	q.wLocker.Lock()
+	err := func() error {
+		g, ok := q.groups[group]
+		if !ok {
+			g = &consumerGroup{strategy: strategy}
+			q.groups[group] = g
+		} else if g.strategy != strategy {
+			return fmt.Errorf("group %s uses strategy %v, not %v", group, g.strategy, strategy)
+		}
+		g.members = append(g.members, id)
+		q.memberships[id] = group
+		q.consumerBuffers[id] = buffer
+		q.snapshot()
+		return nil
+	}()
+	q.wLocker.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return &consumer[T]{
+		id:     id,
+		q:      q,
+		buffer: buffer,
+	}, nil
+}
+
+// groupSnapshot returns the buffers of the members of a group, false if the group was deleted. The lock must be held
+func (q *queue[T]) groupSnapshot(name string) (groupBuffers[T], bool) {
+	g, ok := q.groups[name]
+	if !ok {
+		return groupBuffers[T]{}, false
+	}
+	for _, group := range q.groupBuffers {
+		if group.group == g {
+			return group, true
+		}
+	}
+	return groupBuffers[T]{}, false
+}
+
+// leaveGroup removes a consumer from its group. The write lock must be held
+func (q *queue[T]) leaveGroup(id string) {
+	name, ok := q.memberships[id]
+	if !ok {
+		return
+	}
+	delete(q.memberships, id)
+	g := q.groups[name]
+	members := make([]string, 0, len(g.members))
+	for _, member := range g.members {
+		if member != id {
+			members = append(members, member)
+		}
+	}
+	g.members = members
+	if len(members) == 0 {
+		delete(q.groups, name)
+	}
+}
@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrBufferFull = errors.New("consumer buffer is full")
+var ErrEvicted = errors.New("consumer evicted")
+
+// OverflowPolicy decides what happens to a value produced for a consumer whose buffer is full
+type OverflowPolicy int
+
+const (
+	// Block makes the producer wait until the consumer consumes a value or is cancelled
+	Block OverflowPolicy = iota
+	// DropOldest discards the oldest buffered value to make room for the new one
+	DropOldest
+	// DropNewest discards the new value
+	DropNewest
+	// Fail discards the new value and makes Produce return ErrBufferFull
+	Fail
+	// Evict discards the buffered values and removes the consumer, whose next Consume returns ErrEvicted
+	Evict
+)
+
+func (o OverflowPolicy) String() string {
+	switch o {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop oldest"
+	case DropNewest:
+		return "drop newest"
+	case Fail:
+		return "fail"
+	case Evict:
+		return "evict"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(o))
+	}
+}
+
+// A TypedBoundedConsumer buffers at most Capacity values
+type TypedBoundedConsumer[T any] interface {
+	TypedConsumer[T]
+	Capacity() int
+	// Dropped returns how many values were discarded because of the OverflowPolicy, including the values lost on eviction
+	Dropped() uint64
+}
+
+// BoundedConsumer is the untyped TypedBoundedConsumer
+type BoundedConsumer = TypedBoundedConsumer[interface{}]
+
+type boundedConsumer[T any] struct {
+	consumer[T]
+	capacity int
+}
+
+var _ BoundedConsumer = (*boundedConsumer[interface{}])(nil)
+
+func (b *boundedConsumer[T]) Capacity() int {
+	return b.capacity
+}
+
+func (b *boundedConsumer[T]) Dropped() uint64 {
+	return b.buffer.droppedCount()
+}
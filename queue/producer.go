@@ -1,18 +1,21 @@
 package queue
 
-type Producer interface {
-	Produce(value interface{}) error
+type TypedProducer[T any] interface {
+	Produce(value T) error
 }
 
-type producer struct {
+// Producer is the untyped TypedProducer
+type Producer = TypedProducer[interface{}]
+
+type producer[T any] struct {
 	id string
-	q  produceable
+	q  produceable[T]
 }
 
-func (p *producer) Produce(value interface{}) error {
+func (p *producer[T]) Produce(value T) error {
 	return p.q.produce(p.id, value)
 }
 
-type produceable interface {
-	produce(id string, value interface{}) error
+type produceable[T any] interface {
+	produce(id string, value T) error
 }
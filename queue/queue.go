@@ -1,122 +1,437 @@
 package queue
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/segmentio/ksuid"
 	"sync"
+	"sync/atomic"
 )
 
-type Queue interface {
-	produceable
-	consumable
-	NewProducer() (Producer, error)
-	NewConsumer() (Consumer, error)
+// A TypedQueue delivers the values of type T produced by its producers to every one of its consumers
+type TypedQueue[T any] interface {
+	produceable[T]
+	consumable[T]
+	NewProducer() (TypedProducer[T], error)
+	NewConsumer() (TypedConsumer[T], error)
+	// NewBoundedConsumer creates a consumer buffering at most capacity values, policy deciding what happens to the others
+	NewBoundedConsumer(capacity int, policy OverflowPolicy) (TypedBoundedConsumer[T], error)
+	// NewGroupConsumer creates a consumer sharing the values delivered to a named group with the other members
+	NewGroupConsumer(group string, strategy GroupStrategy) (TypedConsumer[T], error)
 }
 
-type linkedBuffer struct {
-	root *bufferValue
-	rwm  *sync.RWMutex
-	c    *sync.Cond
+// Queue is the untyped TypedQueue
+type Queue = TypedQueue[interface{}]
+
+// linkedBuffer is the FIFO of a consumer. Values are appended at tail and consumed from root, both in constant time
+type linkedBuffer[T any] struct {
+	root     *bufferValue[T]
+	tail     *bufferValue[T]
+	size     int
+	capacity int
+	policy   OverflowPolicy
+	dropped  uint64
+	err      error
+	rwm      *sync.RWMutex
+	c        *sync.Cond
+	notFull  *sync.Cond
+	// waitingConsumers counts the consumers waiting on c for a value
+	waitingConsumers int
+	// waitingProducers counts the producers waiting on notFull for room
+	waitingProducers int
 }
 
-type bufferValue struct {
-	value      interface{}
-	next       *bufferValue
+type bufferValue[T any] struct {
+	value      T
+	next       *bufferValue[T]
 	producerId string
+	// sequence orders the values delivered to groups, so that values handed over by a cancelled member keep their place
+	sequence uint64
+}
+
+// errClosed is returned when pushing to the buffer of a cancelled or evicted consumer
+var errClosed = errors.New("closed buffer")
+
+func newLinkedBuffer[T any](capacity int, policy OverflowPolicy) *linkedBuffer[T] {
+	rwm := new(sync.RWMutex)
+	return &linkedBuffer[T]{
+		capacity: capacity,
+		policy:   policy,
+		rwm:      rwm,
+		c:        sync.NewCond(rwm),
+		notFull:  sync.NewCond(rwm),
+	}
+}
+
+func (b *linkedBuffer[T]) full() bool {
+	return b.capacity > 0 && b.size >= b.capacity
+}
+
+// push appends a value to the buffer, applying its OverflowPolicy if it is full.
+// It returns true if the consumer must be evicted
+func (b *linkedBuffer[T]) push(qv *bufferValue[T]) (evict bool, err error) {
+	b.rwm.Lock()
+	evict, err = func() (bool, error) {
+		for b.full() && b.policy == Block && b.err == nil {
+			b.waitingProducers++
+			b.notFull.Wait()
+			b.waitingProducers--
+		}
+		if b.err != nil {
+			return false, errClosed
+		}
+		if b.full() {
+			switch b.policy {
+			case DropOldest:
+				b.root = b.root.next
+				if b.root == nil {
+					b.tail = nil
+				}
+				b.size--
+				b.dropped++
+			case DropNewest:
+				b.dropped++
+				return false, nil
+			case Fail:
+				b.dropped++
+				return false, ErrBufferFull
+			case Evict:
+				b.dropped += uint64(b.size) + 1
+				b.root = nil
+				b.tail = nil
+				b.size = 0
+				b.err = ErrEvicted
+				b.c.Broadcast()
+				return true, nil
+			}
+		}
+		if b.tail == nil {
+			b.root = qv
+		} else {
+			b.tail.next = qv
+		}
+		b.tail = qv
+		b.size++
+		b.c.Signal()
+		return false, nil
+	}()
+	b.rwm.Unlock()
+	return evict, err
+}
+
+func (b *linkedBuffer[T]) len() int {
+	b.rwm.RLock()
+	size := b.size
+	b.rwm.RUnlock()
+	return size
+}
+
+// insert adds a value handed over by another buffer before the values produced after it
+func (b *linkedBuffer[T]) insert(qv *bufferValue[T]) error {
+	b.rwm.Lock()
+	err := func() error {
+		if b.err != nil {
+			return errClosed
+		}
+		var previous *bufferValue[T]
+		for current := b.root; current != nil && current.sequence < qv.sequence; current = current.next {
+			previous = current
+		}
+		if previous == nil {
+			qv.next = b.root
+			b.root = qv
+		} else {
+			qv.next = previous.next
+			previous.next = qv
+		}
+		if qv.next == nil {
+			b.tail = qv
+		}
+		b.size++
+		b.c.Signal()
+		return nil
+	}()
+	b.rwm.Unlock()
+	return err
+}
+
+// pop removes the first value of the buffer. The write lock must be held
+func (b *linkedBuffer[T]) pop() T {
+	root := b.root
+	b.root = root.next
+	if b.root == nil {
+		b.tail = nil
+	}
+	b.size--
+	b.notFull.Signal()
+	return root.value
+}
+
+// close empties the buffer, releasing the producers waiting for room and the consumers waiting for a value with err.
+// It returns the values that were still buffered
+func (b *linkedBuffer[T]) close(err error) *bufferValue[T] {
+	b.rwm.Lock()
+	root := b.root
+	b.err = err
+	b.root = nil
+	b.tail = nil
+	b.size = 0
+	b.rwm.Unlock()
+	b.notFull.Broadcast()
+	b.c.Broadcast()
+	return root
+}
+
+func (b *linkedBuffer[T]) consume(ctx context.Context) (T, error) {
+	var zero T
+	if ctx.Done() != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+				b.rwm.Lock()
+				b.c.Broadcast()
+				b.rwm.Unlock()
+			case <-stop:
+			}
+		}()
+	}
+	b.rwm.Lock()
+	value, err := func() (T, error) {
+		for b.root == nil && b.err == nil && ctx.Err() == nil {
+			b.waitingConsumers++
+			b.c.Wait()
+			b.waitingConsumers--
+		}
+		if b.root != nil {
+			return b.pop(), nil
+		}
+		if b.err != nil {
+			return zero, b.err
+		}
+		return zero, ctx.Err()
+	}()
+	b.rwm.Unlock()
+	return value, err
+}
+
+func (b *linkedBuffer[T]) tryConsume() (T, bool, error) {
+	var zero T
+	b.rwm.Lock()
+	value, ok, err := func() (T, bool, error) {
+		if b.root != nil {
+			return b.pop(), true, nil
+		}
+		return zero, false, b.err
+	}()
+	b.rwm.Unlock()
+	return value, ok, err
+}
+
+// consumersWaiting returns the number of consumers waiting for a value
+func (b *linkedBuffer[T]) consumersWaiting() int {
+	b.rwm.RLock()
+	waiting := b.waitingConsumers
+	b.rwm.RUnlock()
+	return waiting
+}
+
+// producersWaiting returns the number of producers waiting for room
+func (b *linkedBuffer[T]) producersWaiting() int {
+	b.rwm.RLock()
+	waiting := b.waitingProducers
+	b.rwm.RUnlock()
+	return waiting
+}
+
+func (b *linkedBuffer[T]) droppedCount() uint64 {
+	b.rwm.RLock()
+	dropped := b.dropped
+	b.rwm.RUnlock()
+	return dropped
 }
 
-type queue struct {
+type queue[T any] struct {
+	// sequence numbers the values delivered to groups. It is first to be aligned for atomic operations
+	sequence        uint64
 	rLocker         sync.Locker
 	wLocker         sync.Locker
-	consumerBuffers map[string]*linkedBuffer
+	consumerBuffers map[string]*linkedBuffer[T]
+	// buffers and groupBuffers are copy on write snapshots of the consumers outside of groups and of the groups,
+	// so that producing does not allocate
+	buffers      []consumerBuffer[T]
+	groupBuffers []groupBuffers[T]
+	groups       map[string]*consumerGroup
+	// memberships maps the id of a group member to the name of its group
+	memberships map[string]string
 }
 
-func newQueue() *queue {
+func newQueue[T any]() *queue[T] {
 	rwm := new(sync.RWMutex)
-	return &queue{
+	return &queue[T]{
 		rLocker:         rwm.RLocker(),
 		wLocker:         rwm,
-		consumerBuffers: map[string]*linkedBuffer{},
+		consumerBuffers: map[string]*linkedBuffer[T]{},
+		groups:          map[string]*consumerGroup{},
+		memberships:     map[string]string{},
 	}
 }
 
-func (q *queue) produce(id string, value interface{}) error {
-	q.rLocker.Lock()
-	for _, buffer := range q.consumerBuffers {
-		qv := &bufferValue{
-			value:      value,
-			producerId: id,
+type consumerBuffer[T any] struct {
+	id     string
+	buffer *linkedBuffer[T]
+}
+
+// snapshot rebuilds buffers and groupBuffers after consumers changed. The write lock must be held
+func (q *queue[T]) snapshot() {
+	buffers := make([]consumerBuffer[T], 0, len(q.consumerBuffers))
+	for id, buffer := range q.consumerBuffers {
+		if _, ok := q.memberships[id]; !ok {
+			buffers = append(buffers, consumerBuffer[T]{id: id, buffer: buffer})
 		}
-		buffer.rwm.RLock()
-		root := buffer.root
-		buffer.rwm.RUnlock()
-		if root == nil {
-			buffer.rwm.Lock()
-			buffer.root = qv
-			buffer.rwm.Unlock()
-		} else {
-			buffer.rwm.RLock()
-			for root.next != nil {
-				root = root.next
-			}
-			buffer.rwm.RUnlock()
-			buffer.rwm.Lock()
-			root.next = qv
-			buffer.rwm.Unlock()
+	}
+	q.buffers = buffers
+	groups := make([]groupBuffers[T], 0, len(q.groups))
+	for _, group := range q.groups {
+		members := make([]consumerBuffer[T], len(group.members))
+		for i, id := range group.members {
+			members[i] = consumerBuffer[T]{id: id, buffer: q.consumerBuffers[id]}
 		}
-		buffer.c.Signal()
+		groups = append(groups, groupBuffers[T]{group: group, members: members})
 	}
-	q.rLocker.Unlock()
-	return nil
+	q.groupBuffers = groups
 }
 
-func (q *queue) consume(id string) (interface{}, error) {
-	q.rLocker.Lock()
+// closeBuffer removes the buffer of a consumer from the queue. The consumer keeps it to report why it was closed.
+// The write lock must be held
+func (q *queue[T]) closeBuffer(id string) (*linkedBuffer[T], bool) {
 	buffer, isPresent := q.consumerBuffers[id]
-	q.rLocker.Unlock()
 	if !isPresent {
-		return nil, fmt.Errorf("unknown consumer")
+		return nil, false
 	}
-	buffer.rwm.Lock()
-	if buffer.root == nil {
-		buffer.c.Wait()
+	delete(q.consumerBuffers, id)
+	q.leaveGroup(id)
+	q.snapshot()
+	return buffer, true
+}
+
+// produce appends value to every consumer buffer and to the buffer of one member of each group. The queue lock is released before pushing so that
+// a producer blocked by a full buffer does not prevent consumers from being created or cancelled
+func (q *queue[T]) produce(id string, value T) error {
+	q.rLocker.Lock()
+	buffers := q.buffers
+	groups := q.groupBuffers
+	q.rLocker.Unlock()
+	var firstErr error
+	for _, cb := range buffers {
+		evict, err := cb.buffer.push(&bufferValue[T]{
+			value:      value,
+			producerId: id,
+		})
+		if err == errClosed {
+			continue
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%w: consumer %s", err, cb.id)
+		}
+		if evict {
+			q.wLocker.Lock()
+			q.closeBuffer(cb.id)
+			q.wLocker.Unlock()
+		}
 	}
-	root := buffer.root
-	buffer.root = buffer.root.next
-	buffer.rwm.Unlock()
-	return root.value, nil
+	var sequence uint64
+	if len(groups) > 0 {
+		sequence = atomic.AddUint64(&q.sequence, 1)
+	}
+	for _, group := range groups {
+		err := group.deliver(&bufferValue[T]{
+			value:      value,
+			producerId: id,
+			sequence:   sequence,
+		}, false)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
 
-func (q *queue) cancel(id string) {
+// cancel closes the buffer of a consumer. The values buffered by a group member are handed to the remaining members,
+// they are only dropped with the last one
+func (q *queue[T]) cancel(id string) {
 	q.wLocker.Lock()
-	delete(q.consumerBuffers, id)
+	group, isMember := q.memberships[id]
+	buffer, isPresent := q.closeBuffer(id)
+	var remaining groupBuffers[T]
+	if isMember {
+		remaining, isMember = q.groupSnapshot(group)
+	}
 	q.wLocker.Unlock()
+	if !isPresent {
+		return
+	}
+	pending := buffer.close(ErrCancelled)
+	if !isMember {
+		return
+	}
+	for pending != nil {
+		next := pending.next
+		pending.next = nil
+		// the value is lost only if the other members were cancelled meanwhile, like when the last member leaves
+		_ = remaining.deliver(pending, true)
+		pending = next
+	}
 }
 
-func (q *queue) NewProducer() (Producer, error) {
-	return &producer{
+func (q *queue[T]) NewProducer() (TypedProducer[T], error) {
+	return &producer[T]{
 		id: ksuid.New().String(),
 		q:  q,
 	}, nil
 }
 
-func (q *queue) NewConsumer() (Consumer, error) {
+func (q *queue[T]) addConsumer(capacity int, policy OverflowPolicy) consumer[T] {
 	id := ksuid.New().String()
-	rwm := new(sync.RWMutex)
+	buffer := newLinkedBuffer[T](capacity, policy)
 	q.wLocker.Lock()
-	q.consumerBuffers[id] = &linkedBuffer{
-		root: nil,
-		rwm:  rwm,
-		c:    sync.NewCond(rwm),
-	}
+	q.consumerBuffers[id] = buffer
+	q.snapshot()
 	q.wLocker.Unlock()
-	return &consumer{
-		id: id,
-		q:  q,
+	return consumer[T]{
+		id:     id,
+		q:      q,
+		buffer: buffer,
+	}
+}
+
+func (q *queue[T]) NewConsumer() (TypedConsumer[T], error) {
+	c := q.addConsumer(0, Block)
+	return &c, nil
+}
+
+func (q *queue[T]) NewBoundedConsumer(capacity int, policy OverflowPolicy) (TypedBoundedConsumer[T], error) {
+	if capacity < 1 {
+		return nil, fmt.Errorf("capacity must be positive, got %d", capacity)
+	}
+	if policy < Block || policy > Evict {
+		return nil, fmt.Errorf("unknown overflow policy %v", policy)
+	}
+	return &boundedConsumer[T]{
+		consumer: q.addConsumer(capacity, policy),
+		capacity: capacity,
 	}, nil
 }
 
+// NewTypedQueue creates a queue whose values are of type T
+func NewTypedQueue[T any]() TypedQueue[T] {
+	return newQueue[T]()
+}
+
+var _ = NewTypedQueue[interface{}]
+
 func NewQueue() Queue {
-	q := newQueue()
-	return q
+	return NewTypedQueue[interface{}]()
 }
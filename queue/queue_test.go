@@ -1,8 +1,13 @@
 package queue
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/raf924/connector-sdk/domain"
 	"math/rand"
+	"reflect"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -134,18 +139,40 @@ func BenchmarkQueue(b *testing.B) {
 	}
 }
 
-func BenchmarkProducer_Produce(b *testing.B) {
-	b.ReportAllocs()
-	q := NewQueue()
-	c, _ := q.NewConsumer()
-	for i := 0; i < b.N; i++ {
-		err := q.produce("", 5)
-		if err != nil {
-			b.Error(err)
+// benchmarkProduce measures producing and consuming a value while every consumer lags behind by backlog values
+func benchmarkProduce(backlog int, consumerCount int) func(b *testing.B) {
+	return func(b *testing.B) {
+		b.ReportAllocs()
+		q := NewQueue()
+		consumers := make([]Consumer, consumerCount)
+		for i := range consumers {
+			consumers[i], _ = q.NewConsumer()
 		}
-		_, err = c.Consume()
-		if err != nil {
-			b.Error(err)
+		for i := 0; i < backlog; i++ {
+			if err := q.produce("", i); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			err := q.produce("", 5)
+			if err != nil {
+				b.Error(err)
+			}
+			for _, c := range consumers {
+				_, err = c.Consume()
+				if err != nil {
+					b.Error(err)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkProducer_Produce(b *testing.B) {
+	for _, backlog := range []int{0, 1000, 10000, 100000} {
+		for _, consumerCount := range []int{1, 8} {
+			b.Run(fmt.Sprintf("backlog=%d/consumers=%d", backlog, consumerCount), benchmarkProduce(backlog, consumerCount))
 		}
 	}
 }
@@ -222,3 +249,262 @@ func TestParallelConsumer(t *testing.T) {
 	_ = p.Produce(5)
 	_ = p.Produce(6)
 }
+
+func TestBoundedConsumer_Overflow(t *testing.T) {
+	tests := []struct {
+		policy   OverflowPolicy
+		err      error
+		consumed []interface{}
+		dropped  uint64
+	}{
+		{policy: DropOldest, consumed: []interface{}{2, 3}, dropped: 1},
+		{policy: DropNewest, consumed: []interface{}{1, 2}, dropped: 1},
+		{policy: Fail, err: ErrBufferFull, consumed: []interface{}{1, 2}, dropped: 1},
+		{policy: Evict, dropped: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			q := NewQueue()
+			p, _ := q.NewProducer()
+			c, err := q.NewBoundedConsumer(2, tt.policy)
+			if err != nil {
+				t.Fatalf("unexpected error = %v", err)
+			}
+			var produceErr error
+			for i := 1; i <= 3; i++ {
+				if err := p.Produce(i); err != nil {
+					produceErr = err
+				}
+			}
+			if !errors.Is(produceErr, tt.err) {
+				t.Errorf("expected %v got %v", tt.err, produceErr)
+			}
+			if c.Dropped() != tt.dropped {
+				t.Errorf("expected %v dropped got %v", tt.dropped, c.Dropped())
+			}
+			if tt.policy == Evict {
+				if _, err := c.Consume(); !errors.Is(err, ErrEvicted) {
+					t.Errorf("expected %v got %v", ErrEvicted, err)
+				}
+				if buffers := len(q.(*queue[interface{}]).consumerBuffers); buffers != 0 {
+					t.Errorf("expected evicted consumer to be removed from the queue got %v buffers", buffers)
+				}
+				return
+			}
+			for _, want := range tt.consumed {
+				if v, _ := c.Consume(); v != want {
+					t.Errorf("expected %v got %v", want, v)
+				}
+			}
+		})
+	}
+}
+
+func TestBoundedConsumer_Block(t *testing.T) {
+	q := NewQueue()
+	p, _ := q.NewProducer()
+	c, _ := q.NewBoundedConsumer(1, Block)
+	buffer := c.(*boundedConsumer[interface{}]).buffer
+	waitProducer := func() {
+		for buffer.producersWaiting() == 0 {
+			runtime.Gosched()
+		}
+	}
+	_ = p.Produce(1)
+	produced := make(chan struct{})
+	go func() {
+		_ = p.Produce(2)
+		close(produced)
+	}()
+	waitProducer()
+	select {
+	case <-produced:
+		t.Fatalf("expected producer to block on a full buffer")
+	default:
+	}
+	if v, _ := c.Consume(); v != 1 {
+		t.Errorf("expected %v got %v", 1, v)
+	}
+	<-produced
+	if v, _ := c.Consume(); v != 2 {
+		t.Errorf("expected %v got %v", 2, v)
+	}
+
+	_ = p.Produce(3)
+	go func() {
+		waitProducer()
+		c.Cancel()
+	}()
+	if err := p.Produce(4); err != nil {
+		t.Errorf("unexpected error = %v", err)
+	}
+}
+
+func TestConsumer_CancelWakesConsumer(t *testing.T) {
+	q := NewQueue()
+	c, _ := q.NewConsumer()
+	errs := make(chan error)
+	go func() {
+		_, err := c.Consume()
+		errs <- err
+	}()
+	for c.(*consumer[interface{}]).buffer.consumersWaiting() == 0 {
+		runtime.Gosched()
+	}
+	c.Cancel()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrCancelled) {
+			t.Errorf("expected %v got %v", ErrCancelled, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected Cancel to wake the consumer")
+	}
+}
+
+func TestConsumer_ConsumeContext(t *testing.T) {
+	q := NewQueue()
+	p, _ := q.NewProducer()
+	c, _ := q.NewConsumer()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.ConsumeContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v got %v", context.DeadlineExceeded, err)
+	}
+	_ = p.Produce(5)
+	if v, err := c.ConsumeContext(context.Background()); v != 5 || err != nil {
+		t.Errorf("expected %v got %v, %v", 5, v, err)
+	}
+}
+
+func TestConsumer_TryConsume(t *testing.T) {
+	q := NewQueue()
+	p, _ := q.NewProducer()
+	c, _ := q.NewConsumer()
+	if _, ok, err := c.TryConsume(); ok || err != nil {
+		t.Errorf("expected no value got %v, %v", ok, err)
+	}
+	_ = p.Produce(5)
+	if v, ok, _ := c.TryConsume(); !ok || v != 5 {
+		t.Errorf("expected %v got %v", 5, v)
+	}
+	c.Cancel()
+	if _, _, err := c.TryConsume(); !errors.Is(err, ErrCancelled) {
+		t.Errorf("expected %v got %v", ErrCancelled, err)
+	}
+	if buffers := len(q.(*queue[interface{}]).consumerBuffers); buffers != 0 {
+		t.Errorf("expected cancelled consumer to be removed from the queue got %v buffers", buffers)
+	}
+}
+
+func TestQueue_RefillAfterDrain(t *testing.T) {
+	q := NewQueue()
+	p, _ := q.NewProducer()
+	c, _ := q.NewBoundedConsumer(2, DropOldest)
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 3; i++ {
+			_ = p.Produce(round*10 + i)
+		}
+		for _, want := range []int{round*10 + 1, round*10 + 2} {
+			if v, _ := c.Consume(); v != want {
+				t.Errorf("expected %v got %v", want, v)
+			}
+		}
+		if _, ok, _ := c.TryConsume(); ok {
+			t.Errorf("expected drained buffer")
+		}
+	}
+}
+
+func TestTypedExchange(t *testing.T) {
+	requests := NewTypedQueue[domain.ServerMessage]()
+	responses := NewTypedQueue[domain.ServerMessage]()
+	bot, _ := NewTypedExchange(requests, responses)
+	connector, _ := NewTypedExchange(responses, requests)
+	message := domain.NewChatMessage("test", domain.NewUser("user", "id", domain.RegularUser), nil, false, false, time.Now(), false)
+	if err := connector.Produce(message); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	received, err := bot.Consume()
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if chatMessage, ok := received.(*domain.ChatMessage); !ok || chatMessage.Id() != message.Id() {
+		t.Errorf("expected %v got %v", message, received)
+	}
+}
+
+func TestGroupConsumer(t *testing.T) {
+	tests := []struct {
+		strategy GroupStrategy
+		want     [][]interface{}
+	}{
+		{strategy: RoundRobin, want: [][]interface{}{{1, 3}, {2, 4}}},
+		{strategy: LeastLoaded, want: [][]interface{}{{1, 3}, {2, 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			q := NewQueue()
+			p, _ := q.NewProducer()
+			broadcast, _ := q.NewConsumer()
+			first, _ := q.NewGroupConsumer("workers", tt.strategy)
+			second, _ := q.NewGroupConsumer("workers", tt.strategy)
+			if _, err := q.NewGroupConsumer("workers", (tt.strategy+1)%2); err == nil {
+				t.Errorf("expected joining with another strategy to fail")
+			}
+			for i := 1; i <= 4; i++ {
+				_ = p.Produce(i)
+			}
+			for i, member := range []Consumer{first, second} {
+				var got []interface{}
+				for {
+					v, ok, _ := member.TryConsume()
+					if !ok {
+						break
+					}
+					got = append(got, v)
+				}
+				if !reflect.DeepEqual(got, tt.want[i]) {
+					t.Errorf("expected member %d to receive %v got %v", i, tt.want[i], got)
+				}
+			}
+			for i := 1; i <= 4; i++ {
+				if v, _, _ := broadcast.TryConsume(); v != i {
+					t.Errorf("expected %v got %v", i, v)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupConsumer_Cancel(t *testing.T) {
+	q := NewQueue()
+	p, _ := q.NewProducer()
+	first, _ := q.NewGroupConsumer("workers", LeastLoaded)
+	second, _ := q.NewGroupConsumer("workers", LeastLoaded)
+	_ = p.Produce(1)
+	_ = p.Produce(2)
+	_ = p.Produce(3)
+	first.Cancel()
+	_ = p.Produce(4)
+	var got []interface{}
+	for {
+		v, ok, _ := second.TryConsume()
+		if !ok {
+			break
+		}
+		got = append(got, v)
+	}
+	if !reflect.DeepEqual(got, []interface{}{1, 2, 3, 4}) {
+		t.Errorf("expected %v got %v", []interface{}{1, 2, 3, 4}, got)
+	}
+	groups := q.(*queue[interface{}]).groupBuffers
+	second.Cancel()
+	// a producer still holding the group cancelled meanwhile is told its value is dropped
+	if err := groups[0].deliver(&bufferValue[interface{}]{value: 5}, false); !errors.Is(err, ErrNoMember) {
+		t.Errorf("expected %v got %v", ErrNoMember, err)
+	}
+	if _, err := q.NewGroupConsumer("workers", RoundRobin); err != nil {
+		t.Errorf("expected empty group to be deleted got %v", err)
+	}
+}
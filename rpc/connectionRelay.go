@@ -34,6 +34,10 @@ func (e emptyMessage) unimplementedMessageMethod() {}
 
 type ChatMessage struct {
 	emptyMessage
+	Id        string
+	ReplyTo   string
+	ThreadId  string
+	Channel   string
 	Message   string
 	Recipient string
 	Private   bool
@@ -49,6 +53,11 @@ type InviteMessage struct {
 	Recipient string
 }
 
+// A ConnectionRelay connects to a chat platform.
+// Recv sets the ids the platform gives to a message, its thread and, when the connector bridges several rooms, its room
+// with domain.WithId, domain.WithThreadId and domain.WithChannel.
+// Send uses ClientMessage.ReplyTo, ClientMessage.ThreadId and ClientMessage.Channel the same way when the platform supports them.
+// Platforms with formatting render ClientMessage.Content and parse the text they receive with the Renderer of their markup, see GetRenderer
 type ConnectionRelay interface {
 	Recv() (*domain.ChatMessage, error)
 	Send(message *domain.ClientMessage) error
@@ -24,7 +24,10 @@ func GetConnectorRelay(relayKey string) ConnectorRelayBuilder {
 
 var _ = GetConnectorRelay
 
+// A ConnectorRelay connects a connector to the bots dispatching its messages
 type ConnectorRelay interface {
+	// Start starts accepting bots. ctx bounds the lifetime of the relay and of every Dispatcher it accepts:
+	// once it is done, Accept and Recv return, the Dispatchers are done and Err returns the error of ctx
 	Start(ctx context.Context, botUser *domain.User, onlineUsers domain.UserList, trigger string) error
 	Accept() (Dispatcher, error)
 	Recv() (*domain.ClientMessage, error)
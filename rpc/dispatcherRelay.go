@@ -23,6 +23,8 @@ func GetDispatcherRelay(relayKey string) DispatcherRelayBuilder {
 
 var _ = GetDispatcherRelay
 
+// A DispatcherRelay connects a bot to a connector.
+// Message ids, reply-to ids and thread ids must be carried across the relay both ways
 type DispatcherRelay interface {
 	Connect(registration *domain.RegistrationMessage) (*domain.ConfirmationMessage, error)
 	Send(packet *domain.ClientMessage) error
@@ -30,3 +32,8 @@ type DispatcherRelay interface {
 	Done() <-chan struct{}
 	Err() error
 }
+
+// A RegistrationUpdater is a DispatcherRelay able to update the commands it registered with Connect without reconnecting
+type RegistrationUpdater interface {
+	UpdateRegistration(registration *domain.RegistrationMessage) error
+}
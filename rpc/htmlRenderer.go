@@ -0,0 +1,218 @@
+package rpc
+
+import (
+	"github.com/raf924/connector-sdk/domain"
+	"html"
+	"strings"
+	"unicode"
+)
+
+var htmlStyles = []struct {
+	style domain.Style
+	tag   string
+}{
+	{domain.Bold, "b"},
+	{domain.Italic, "i"},
+	{domain.Underline, "u"},
+	{domain.Strikethrough, "s"},
+	{domain.Monospace, "code"},
+}
+
+// htmlBlocks are the tags starting a new line when parsing
+var htmlBlocks = map[string]bool{
+	"p": true, "div": true, "pre": true, "blockquote": true, "ul": true, "ol": true, "li": true, "table": true, "tr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// htmlRenderer produces the HTML subset accepted by HTML-based chats such as Matrix
+type htmlRenderer struct{}
+
+var _ Renderer = htmlRenderer{}
+
+func htmlText(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+}
+
+func htmlStyle(text string, style domain.Style) string {
+	text = htmlText(text)
+	for i := len(htmlStyles) - 1; i >= 0; i-- {
+		if style.Has(htmlStyles[i].style) {
+			text = "<" + htmlStyles[i].tag + ">" + text + "</" + htmlStyles[i].tag + ">"
+		}
+	}
+	return text
+}
+
+func htmlLink(text string, url string) string {
+	if len(text) == 0 {
+		text = url
+	}
+	return `<a href="` + html.EscapeString(url) + `">` + htmlText(text) + "</a>"
+}
+
+func (htmlRenderer) Render(content *domain.Content) string {
+	if content == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, block := range content.Blocks() {
+		switch b := block.(type) {
+		case domain.Span:
+			sb.WriteString(htmlStyle(b.Text, b.Style))
+		case domain.LineBreak:
+			sb.WriteString("<br>")
+		case domain.CodeBlock:
+			class := ""
+			if len(b.Language) > 0 {
+				class = ` class="language-` + html.EscapeString(b.Language) + `"`
+			}
+			sb.WriteString("<pre><code" + class + ">" + html.EscapeString(strings.TrimSuffix(b.Code, "\n")) + "</code></pre>")
+		case domain.Link:
+			sb.WriteString(htmlLink(b.Text, b.URL))
+		case domain.Attachment:
+			if len(b.URL) > 0 {
+				sb.WriteString(htmlLink(b.URL, b.URL))
+			} else {
+				sb.WriteString(htmlText(b.PlainText()))
+			}
+		case domain.Embed:
+			var lines []string
+			if len(b.Title) > 0 {
+				lines = append(lines, htmlStyle(b.Title, domain.Bold))
+			}
+			if len(b.Description) > 0 {
+				lines = append(lines, htmlText(b.Description))
+			}
+			for _, field := range b.Fields {
+				lines = append(lines, htmlStyle(field.Name+":", domain.Bold)+" "+htmlText(field.Value))
+			}
+			if len(b.URL) > 0 {
+				lines = append(lines, htmlLink(b.URL, b.URL))
+			}
+			if len(b.ImageURL) > 0 {
+				lines = append(lines, `<img src="`+html.EscapeString(b.ImageURL)+`">`)
+			}
+			if len(lines) > 0 {
+				sb.WriteString("<blockquote>" + strings.Join(lines, "<br>") + "</blockquote>")
+			}
+		}
+	}
+	return sb.String()
+}
+
+func collapseSpace(text string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		sb.WriteRune(r)
+	}
+	if space {
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+// htmlAttribute returns the value of an attribute of a tag such as `a href="url"`
+func htmlAttribute(tag string, name string) string {
+	i := strings.Index(tag, name+"=")
+	if i < 0 {
+		return ""
+	}
+	value := tag[i+len(name)+1:]
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return html.UnescapeString(value[1 : end+1])
+		}
+		return ""
+	}
+	if end := strings.IndexAny(value, " \t\n/"); end >= 0 {
+		value = value[:end]
+	}
+	return html.UnescapeString(value)
+}
+
+// Parse strips tags and unescapes entities. Line breaks and block elements become new lines
+// and whitespace outside of <pre> is collapsed like a browser would. Links left open keep their URL
+func (htmlRenderer) Parse(text string) string {
+	var sb strings.Builder
+	newLine := func() {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	pre := 0
+	var links []struct {
+		href  string
+		start int
+	}
+	// closeLink appends the href of the innermost open link after its label
+	closeLink := func() {
+		link := links[len(links)-1]
+		links = links[:len(links)-1]
+		if label := sb.String()[link.start:]; len(link.href) > 0 && len(label) > 0 && label != link.href {
+			sb.WriteString(" (" + link.href + ")")
+		} else if len(label) == 0 {
+			sb.WriteString(link.href)
+		}
+	}
+	for len(text) > 0 {
+		start := strings.IndexByte(text, '<')
+		end := -1
+		if start >= 0 {
+			end = strings.IndexByte(text[start:], '>')
+		}
+		if start < 0 || end < 0 {
+			start, end = len(text), 0
+		}
+		raw := text[:start]
+		if pre == 0 {
+			raw = collapseSpace(raw)
+			if sb.Len() == 0 || strings.HasSuffix(sb.String(), "\n") {
+				raw = strings.TrimPrefix(raw, " ")
+			}
+		}
+		sb.WriteString(html.UnescapeString(raw))
+		if start == len(text) {
+			break
+		}
+		tag := strings.TrimSpace(text[start+1 : start+end])
+		text = text[start+end+1:]
+		closing := strings.HasPrefix(tag, "/")
+		name := ""
+		if fields := strings.Fields(strings.TrimPrefix(tag, "/")); len(fields) > 0 {
+			name = strings.ToLower(strings.TrimRight(fields[0], "/"))
+		}
+		switch {
+		case name == "br":
+			sb.WriteString("\n")
+		case name == "a" && !closing:
+			links = append(links, struct {
+				href  string
+				start int
+			}{href: htmlAttribute(tag, "href"), start: sb.Len()})
+		case name == "a" && len(links) > 0:
+			closeLink()
+		case htmlBlocks[name]:
+			if name == "pre" {
+				if closing {
+					pre--
+				} else {
+					pre++
+				}
+			}
+			newLine()
+		}
+	}
+	for len(links) > 0 {
+		closeLink()
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
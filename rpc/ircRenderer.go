@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+)
+
+const (
+	ircBold          = "\x02"
+	ircColor         = "\x03"
+	ircHexColor      = "\x04"
+	ircMonospace     = "\x11"
+	ircReverse       = "\x16"
+	ircItalic        = "\x1D"
+	ircStrikethrough = "\x1E"
+	ircUnderline     = "\x1F"
+	ircReset         = "\x0F"
+)
+
+var ircStyles = []struct {
+	style domain.Style
+	code  string
+}{
+	{domain.Bold, ircBold},
+	{domain.Italic, ircItalic},
+	{domain.Underline, ircUnderline},
+	{domain.Strikethrough, ircStrikethrough},
+	{domain.Monospace, ircMonospace},
+}
+
+// ircRenderer uses the formatting control codes of IRC clients. Styles are applied line by line since clients reset them at the end of a line
+type ircRenderer struct{}
+
+var _ Renderer = ircRenderer{}
+var _ domain.Formatter = ircRenderer{}
+
+func (i ircRenderer) Render(content *domain.Content) string {
+	return domain.Format(content, i)
+}
+
+func ircStyle(text string, style domain.Style) string {
+	var codes string
+	for _, s := range ircStyles {
+		if style.Has(s.style) {
+			codes += s.code
+		}
+	}
+	if len(codes) == 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for j, line := range lines {
+		if len(line) > 0 {
+			lines[j] = codes + line + ircReset
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (ircRenderer) Span(span domain.Span) string {
+	return ircStyle(span.Text, span.Style)
+}
+
+func (ircRenderer) CodeBlock(block domain.CodeBlock) string {
+	return ircStyle(strings.TrimSuffix(block.Code, "\n"), domain.Monospace)
+}
+
+func (ircRenderer) Link(link domain.Link) string {
+	return link.PlainText()
+}
+
+func (ircRenderer) Attachment(attachment domain.Attachment) string {
+	return attachment.PlainText()
+}
+
+func (ircRenderer) Embed(embed domain.Embed) string {
+	var lines []string
+	if len(embed.Title) > 0 {
+		lines = append(lines, ircStyle(embed.Title, domain.Bold))
+	}
+	if len(embed.Description) > 0 {
+		lines = append(lines, embed.Description)
+	}
+	for _, field := range embed.Fields {
+		lines = append(lines, ircStyle(field.Name+":", domain.Bold)+" "+field.Value)
+	}
+	if len(embed.URL) > 0 {
+		lines = append(lines, embed.URL)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// skipColor returns the length of the color arguments at the start of text: between min and max characters matching digit,
+// optionally followed by a comma and another color. Fewer than min characters are not a color, the code alone resets colors
+func skipColor(text string, min int, max int, digit func(byte) bool) int {
+	n := 0
+	for n < max && n < len(text) && digit(text[n]) {
+		n++
+	}
+	if n < min {
+		return 0
+	}
+	if n >= len(text) || text[n] != ',' {
+		return n
+	}
+	m := 0
+	for m < max && n+1+m < len(text) && digit(text[n+1+m]) {
+		m++
+	}
+	if m < min {
+		return n
+	}
+	return n + 1 + m
+}
+
+// Parse removes formatting and color codes. The codes are ASCII so they are matched on bytes, leaving multibyte characters intact
+func (ircRenderer) Parse(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case ircBold[0], ircMonospace[0], ircReverse[0], ircItalic[0], ircStrikethrough[0], ircUnderline[0], ircReset[0]:
+		case ircColor[0]:
+			i += skipColor(text[i+1:], 1, 2, isDigit)
+		case ircHexColor[0]:
+			i += skipColor(text[i+1:], 6, 6, isHexDigit)
+		default:
+			sb.WriteByte(text[i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,309 @@
+package rpc
+
+import (
+	"github.com/raf924/connector-sdk/domain"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var markdownStyles = []struct {
+	style  domain.Style
+	marker string
+}{
+	{domain.Bold, "**"},
+	{domain.Italic, "*"},
+	{domain.Underline, "__"},
+	{domain.Strikethrough, "~~"},
+}
+
+const markdownEscaped = "\\*_~`[]()<>|#"
+
+// markdownRenderer uses the markdown flavour of Discord-like platforms, where __ underlines text
+type markdownRenderer struct{}
+
+var _ Renderer = markdownRenderer{}
+var _ domain.Formatter = markdownRenderer{}
+
+func (m markdownRenderer) Render(content *domain.Content) string {
+	return domain.Format(content, m)
+}
+
+func markdownEscape(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(markdownEscaped, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func markdownCode(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") || len(fence) > 1 {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// markdownStyle wraps the text between its leading and trailing whitespace since markers cannot be next to spaces
+func markdownStyle(text string, style domain.Style) string {
+	core := strings.TrimSpace(text)
+	if len(core) == 0 {
+		return text
+	}
+	start := strings.Index(text, core)
+	if style.Has(domain.Monospace) {
+		core = markdownCode(core)
+	} else {
+		core = markdownEscape(core)
+	}
+	for i := len(markdownStyles) - 1; i >= 0; i-- {
+		if style.Has(markdownStyles[i].style) {
+			core = markdownStyles[i].marker + core + markdownStyles[i].marker
+		}
+	}
+	return text[:start] + core + text[start+len(strings.TrimSpace(text)):]
+}
+
+func (markdownRenderer) Span(span domain.Span) string {
+	lines := strings.Split(span.Text, "\n")
+	for i, line := range lines {
+		lines[i] = markdownStyle(line, span.Style)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (markdownRenderer) CodeBlock(block domain.CodeBlock) string {
+	code := strings.TrimSuffix(block.Code, "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + block.Language + "\n" + code + "\n" + fence
+}
+
+var (
+	markdownURLEscaper   = strings.NewReplacer("(", "%28", ")", "%29", " ", "%20", ">", "%3E")
+	markdownURLUnescaper = strings.NewReplacer("%28", "(", "%29", ")", "%20", " ", "%3E", ">")
+)
+
+// markdownURL encodes the characters ending a link destination, Parse decodes them back
+func markdownURL(url string) string {
+	return markdownURLEscaper.Replace(url)
+}
+
+func (markdownRenderer) Link(link domain.Link) string {
+	if len(link.Text) == 0 || link.Text == link.URL {
+		return "<" + markdownURL(link.URL) + ">"
+	}
+	return "[" + markdownEscape(link.Text) + "](" + markdownURL(link.URL) + ")"
+}
+
+func (markdownRenderer) Attachment(attachment domain.Attachment) string {
+	if len(attachment.URL) > 0 {
+		return "<" + markdownURL(attachment.URL) + ">"
+	}
+	return markdownEscape(attachment.PlainText())
+}
+
+func (markdownRenderer) Embed(embed domain.Embed) string {
+	var lines []string
+	if len(embed.Title) > 0 {
+		lines = append(lines, markdownStyle(embed.Title, domain.Bold))
+	}
+	if len(embed.Description) > 0 {
+		lines = append(lines, strings.Split(markdownEscape(embed.Description), "\n")...)
+	}
+	for _, field := range embed.Fields {
+		lines = append(lines, markdownStyle(field.Name+":", domain.Bold)+" "+markdownEscape(field.Value))
+	}
+	if len(embed.URL) > 0 {
+		lines = append(lines, "<"+markdownURL(embed.URL)+">")
+	}
+	for i, line := range lines {
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Parse removes emphasis markers, escapes, block quotes and code fences. Underscores inside words are kept as in snake_case
+func (markdownRenderer) Parse(text string) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			out = append(out, parseMarkdownInline(strings.Join(paragraph, "\n")))
+			paragraph = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if fence := markdownFence(line); len(fence) > 0 {
+			end := i + 1
+			for end < len(lines) && strings.TrimSpace(lines[end]) != fence {
+				end++
+			}
+			if end < len(lines) {
+				flush()
+				out = append(out, lines[i+1:end]...)
+				i = end
+				continue
+			}
+		}
+		if strings.HasPrefix(line, ">") {
+			line = strings.TrimPrefix(strings.TrimPrefix(line, ">"), " ")
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// markdownFence returns the backticks opening a code block on line, if any
+func markdownFence(line string) string {
+	n := 0
+	for n < len(line) && line[n] == '`' {
+		n++
+	}
+	if n < 3 || strings.Contains(line[n:], "`") {
+		return ""
+	}
+	return line[:n]
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// closing returns the index of the marker closing an emphasis opened at the start of text, -1 if there is none.
+// A run of three markers closes both a single and a double marker, as in ***text***
+func closing(text string, marker string) int {
+	for i := len(marker); i < len(text); i++ {
+		switch {
+		case text[i] == '\\':
+			i++
+		case text[i] == '`':
+			n := strings.IndexByte(text[i+1:], '`')
+			if n < 0 {
+				return -1
+			}
+			i += n + 1
+		case text[i] == marker[0]:
+			run := 1
+			for i+run < len(text) && text[i+run] == marker[0] {
+				run++
+			}
+			before, _ := utf8.DecodeLastRuneInString(text[:i])
+			after, _ := utf8.DecodeRuneInString(text[i+run:])
+			if i == len(marker) || unicode.IsSpace(before) || (marker[0] == '_' && isWordRune(after)) {
+				i += run - 1
+				continue
+			}
+			switch run {
+			case len(marker):
+				return i
+			case 3:
+				return i + run - len(marker)
+			}
+			i += run - 1
+		}
+	}
+	return -1
+}
+
+func parseMarkdownInline(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.IndexByte(markdownEscaped+"!\"$%&'+,-./:;=?@^{}", rest[1]) >= 0:
+			sb.WriteByte(rest[1])
+			i += 2
+			continue
+		case rest[0] == '`':
+			n := 0
+			for n < len(rest) && rest[n] == '`' {
+				n++
+			}
+			fence := rest[:n]
+			if end := strings.Index(rest[n:], fence); end >= 0 {
+				code := rest[n : n+end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				sb.WriteString(code)
+				i += 2*n + end
+				continue
+			}
+		case rest[0] == '<':
+			if end := strings.IndexByte(rest, '>'); end > 0 && strings.Contains(rest[1:end], "://") && !strings.ContainsAny(rest[1:end], " \n") {
+				sb.WriteString(markdownURLUnescaper.Replace(rest[1:end]))
+				i += end + 1
+				continue
+			}
+		case rest[0] == '[':
+			if text, url, n, ok := markdownLink(rest); ok {
+				sb.WriteString(domain.Link{Text: parseMarkdownInline(text), URL: markdownURLUnescaper.Replace(url)}.PlainText())
+				i += n
+				continue
+			}
+		}
+		matched := false
+		for _, marker := range []string{"**", "__", "~~", "*", "_"} {
+			if !strings.HasPrefix(rest, marker) || len(rest) <= len(marker) {
+				continue
+			}
+			if next, _ := utf8.DecodeRuneInString(rest[len(marker):]); unicode.IsSpace(next) {
+				continue
+			}
+			if previous, _ := utf8.DecodeLastRuneInString(text[:i]); marker[0] == '_' && i > 0 && isWordRune(previous) {
+				continue
+			}
+			if end := closing(rest, marker); end > 0 {
+				sb.WriteString(parseMarkdownInline(rest[len(marker):end]))
+				i += end + len(marker)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sb.WriteByte(rest[0])
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// markdownLink parses a [text](url) link at the start of text, returning its length
+func markdownLink(text string) (string, string, int, bool) {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if i+1 >= len(text) || text[i+1] != '(' {
+				return "", "", 0, false
+			}
+			end := strings.IndexByte(text[i+2:], ')')
+			if end < 0 {
+				return "", "", 0, false
+			}
+			return text[1:i], text[i+2 : i+2+end], i + 3 + end, true
+		}
+	}
+	return "", "", 0, false
+}
@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"github.com/raf924/connector-sdk/domain"
+)
+
+var renderers = map[string]Renderer{}
+
+// A Renderer converts between the rich content of the SDK and the markup of a chat platform.
+// A ConnectionRelay renders ClientMessage.Content before sending it and parses the text it receives
+// so that commands see plain text whatever the platform
+type Renderer interface {
+	// Render formats content in the markup of the platform
+	Render(content *domain.Content) string
+	// Parse strips the markup of a formatted text, rendering links, code blocks and quotes like domain.PlainText
+	Parse(text string) string
+}
+
+func RegisterRenderer(key string, renderer Renderer) {
+	renderers[key] = renderer
+}
+
+var _ = RegisterRenderer
+
+func GetRenderer(key string) Renderer {
+	if renderer, ok := renderers[key]; ok {
+		return renderer
+	}
+	return nil
+}
+
+var _ = GetRenderer
+
+func init() {
+	RegisterRenderer("plain", plainRenderer{})
+	RegisterRenderer("irc", ircRenderer{})
+	RegisterRenderer("markdown", markdownRenderer{})
+	RegisterRenderer("html", htmlRenderer{})
+}
+
+type plainRenderer struct{}
+
+func (plainRenderer) Render(content *domain.Content) string {
+	return domain.PlainText(content)
+}
+
+func (plainRenderer) Parse(text string) string {
+	return text
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"github.com/raf924/connector-sdk/domain"
+	"testing"
+)
+
+var testContents = map[string]*domain.Content{
+	"styles":      domain.NewContent().Text("hello ", domain.Bold).Text("big ", domain.Bold|domain.Italic).Text("snake_case*", domain.PlainStyle).Text("code", domain.Monospace),
+	"links":       domain.NewContent(domain.Link{Text: "docs", URL: "https://example.com/a_b"}, domain.Span{Text: " "}, domain.Link{URL: "https://example.org"}),
+	"url escapes": domain.NewContent(domain.Link{Text: "Go", URL: "https://en.wikipedia.org/wiki/Go_(lang)"}, domain.Span{Text: " "}, domain.Link{URL: "https://example.com/a b>c"}),
+	"code block":  domain.NewContent(domain.Span{Text: "result:"}, domain.CodeBlock{Language: "go", Code: "x := a * b\n"}, domain.Span{Text: "done"}),
+	"non ascii":   domain.NewContent().Text("café ", domain.Bold).Text("✓ 日本", domain.Italic),
+	"attachments": domain.NewContent(domain.Attachment{URL: "https://example.com/a.png"}, domain.LineBreak{}, domain.Attachment{Name: "log.txt", Data: []byte("log")}),
+	"embed":       domain.NewContent(domain.Span{Text: "see"}, domain.Embed{Title: "Title", Description: "1 < 2", URL: "https://example.com", ImageURL: "https://example.com/a.png", Fields: []domain.EmbedField{{Name: "stars", Value: "5"}}}),
+}
+
+func TestRenderer_RoundTrip(t *testing.T) {
+	for _, key := range []string{"plain", "irc", "markdown", "html"} {
+		renderer := GetRenderer(key)
+		if renderer == nil {
+			t.Fatalf("expected renderer %s to be registered", key)
+		}
+		for name, content := range testContents {
+			t.Run(key+"/"+name, func(t *testing.T) {
+				want := domain.PlainText(content)
+				if got := renderer.Parse(renderer.Render(content)); got != want {
+					t.Errorf("expected %q got %q (rendered %q)", want, got, renderer.Render(content))
+				}
+			})
+		}
+	}
+}
+
+func TestRenderer_Render(t *testing.T) {
+	content := domain.NewContent().Text("bold", domain.Bold).Text(" and ", domain.PlainStyle).Text("both", domain.Bold|domain.Italic).Append(domain.Link{Text: "docs", URL: "https://example.com"})
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "irc", want: "\x02bold\x0F and \x02\x1Dboth\x0Fdocs (https://example.com)"},
+		{key: "markdown", want: "**bold** and ***both***[docs](https://example.com)"},
+		{key: "html", want: `<b>bold</b> and <b><i>both</i></b><a href="https://example.com">docs</a>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := GetRenderer(tt.key).Render(content); got != tt.want {
+				t.Errorf("expected %q got %q", tt.want, got)
+			}
+		})
+	}
+	for _, key := range []string{"plain", "irc", "markdown", "html"} {
+		t.Run(key+"/nil", func(t *testing.T) {
+			if got := GetRenderer(key).Render(nil); got != "" {
+				t.Errorf("expected %q got %q", "", got)
+			}
+		})
+	}
+}
+
+func TestRenderer_Parse(t *testing.T) {
+	tests := []struct {
+		key  string
+		text string
+		want string
+	}{
+		{key: "irc", text: "\x0304,12red\x03 \x04FF0000hex\x04 \x1Fu\x1F", want: "red hex u"},
+		{key: "irc", text: "\x02café\x0F ✓", want: "café ✓"},
+		{key: "irc", text: "\x04face \x04abc123\x04", want: "face "},
+		{key: "html", text: `see <a href="https://example.com">docs`, want: "see docs (https://example.com)"},
+		{key: "markdown", text: "**bold** _it_ ~~gone~~ `a*b` my_var 2 * 3\n> quoted\n```\n**raw**\n```", want: "bold it gone a*b my_var 2 * 3\nquoted\n**raw**"},
+		{key: "html", text: "<p>hello   <b>world</b></p>\n<p>a &amp; b<br/>c</p><pre>  x\n  y</pre>", want: "hello world\na & b\nc\n  x\n  y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := GetRenderer(tt.key).Parse(tt.text); got != tt.want {
+				t.Errorf("expected %q got %q", tt.want, got)
+			}
+		})
+	}
+}
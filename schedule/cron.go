@@ -0,0 +1,181 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Schedule computes the activation times of a job
+type Schedule interface {
+	// Next returns the first activation strictly after the given time, or the zero time if there is none
+	Next(after time.Time) time.Time
+}
+
+type once struct {
+	at time.Time
+}
+
+func (o once) Next(after time.Time) time.Time {
+	if o.at.After(after) {
+		return o.at
+	}
+	return time.Time{}
+}
+
+// At creates a Schedule activating once at the given time
+func At(at time.Time) Schedule {
+	return once{at: at}
+}
+
+type field struct {
+	min, max int
+}
+
+var (
+	minutes  = field{0, 59}
+	hours    = field{0, 23}
+	days     = field{1, 31}
+	months   = field{1, 12}
+	weekdays = field{0, 7}
+)
+
+type cron struct {
+	minutes, hours, days, months, weekdays uint64
+	// restricted day fields are matched with OR, like in crontab(5). A field starting with `*`, such as `*/2`, is not restricted
+	daysRestricted, weekdaysRestricted bool
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+func parseNumber(s string, f field) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%d is out of range [%d-%d]", n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// parseField parses a comma separated list of `*`, `n`, `a-b` with an optional `/step`
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+		start, end := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseNumber(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if end, err = parseNumber(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			n, err := parseNumber(rangePart, f)
+			if err != nil {
+				return 0, err
+			}
+			start = n
+			if step == 1 {
+				end = n
+			}
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// ParseCron parses a standard 5 field cron expression (minute hour day-of-month month day-of-week)
+// or one of the @yearly, @monthly, @weekly, @daily and @hourly descriptors.
+// Both 0 and 7 mean Sunday. Times are computed in the location of the time passed to Next
+func ParseCron(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if descriptor, ok := descriptors[spec]; ok {
+		spec = descriptor
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", spec, len(fields))
+	}
+	c := &cron{
+		daysRestricted:     !strings.HasPrefix(fields[2], "*"),
+		weekdaysRestricted: !strings.HasPrefix(fields[4], "*"),
+	}
+	for i, target := range []struct {
+		bits *uint64
+		f    field
+	}{{&c.minutes, minutes}, {&c.hours, hours}, {&c.days, days}, {&c.months, months}, {&c.weekdays, weekdays}} {
+		bits, err := parseField(fields[i], target.f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+		*target.bits = bits
+	}
+	if c.weekdays&(1<<7) != 0 {
+		c.weekdays |= 1
+	}
+	return c, nil
+}
+
+func (c *cron) matchesDay(t time.Time) bool {
+	dayMatches := c.days&(1<<uint(t.Day())) != 0
+	weekdayMatches := c.weekdays&(1<<uint(t.Weekday())) != 0
+	if c.daysRestricted && c.weekdaysRestricted {
+		return dayMatches || weekdayMatches
+	}
+	return dayMatches && weekdayMatches
+}
+
+func (c *cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// an expression matching no date, such as February 30th, gives up after 5 years
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
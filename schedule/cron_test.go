@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	from := time.Date(2021, time.March, 14, 15, 9, 26, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "* * * * *", want: time.Date(2021, time.March, 14, 15, 10, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", want: time.Date(2021, time.March, 14, 15, 15, 0, 0, time.UTC)},
+		{spec: "0 9 * * *", want: time.Date(2021, time.March, 15, 9, 0, 0, 0, time.UTC)},
+		{spec: "@daily", want: time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{spec: "30 8 * * 1-5", want: time.Date(2021, time.March, 15, 8, 30, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", want: time.Date(2021, time.March, 21, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 12 1,15 * *", want: time.Date(2021, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{spec: "0 0 13 * 5", want: time.Date(2021, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 */2 * 3", want: time.Date(2021, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", want: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", want: time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error = %v", err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCron_Errors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
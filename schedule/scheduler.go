@@ -0,0 +1,281 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/raf924/connector-sdk/command"
+	"github.com/raf924/connector-sdk/domain"
+	"github.com/raf924/connector-sdk/storage"
+	"github.com/segmentio/ksuid"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A Job is a persisted activation of a Handler.
+// Payload holds whatever the handler needs to build its messages, such as the text and recipient of a reminder
+type Job struct {
+	Id      string    `json:"id"`
+	Handler string    `json:"handler"`
+	Cron    string    `json:"cron,omitempty"`
+	At      time.Time `json:"at"`
+	Payload string    `json:"payload,omitempty"`
+	Next    time.Time `json:"next"`
+}
+
+// A Handler builds the messages sent when a Job fires
+type Handler func(ctx context.Context, job Job) ([]*domain.ClientMessage, error)
+
+type Scheduler interface {
+	// Handle registers the handler of the jobs created with name. Handlers must be registered before Start
+	// so that jobs restored from the storage can run
+	Handle(name string, handler Handler)
+	// Schedule creates a job firing according to a cron expression
+	Schedule(handler string, cron string, payload string) (Job, error)
+	// ScheduleAt creates a job firing once
+	ScheduleAt(handler string, at time.Time, payload string) (Job, error)
+	Cancel(id string) error
+	Jobs() []Job
+	// Start runs the jobs until ctx is done. The persisted jobs are restored on first use, so jobs scheduled before Start are kept alongside them.
+	// Jobs that should have fired while the bot was stopped fire immediately
+	Start(ctx context.Context) error
+}
+
+var _ Scheduler = (*scheduler)(nil)
+
+type scheduledJob struct {
+	job      Job
+	schedule Schedule
+}
+
+type scheduler struct {
+	m        *sync.Mutex
+	store    storage.Storage
+	sink     command.ReplySink
+	handlers map[string]Handler
+	jobs     map[string]*scheduledJob
+	wake     chan struct{}
+	now      func() time.Time
+	// loaded is set once the persisted jobs are restored, loadErr keeps the error if they could not be
+	loaded  bool
+	loadErr error
+}
+
+func (s *scheduler) Handle(name string, handler Handler) {
+	s.m.Lock()
+	s.handlers[name] = handler
+	s.m.Unlock()
+}
+
+// save persists the jobs and wakes the run loop up. The lock must be held
+func (s *scheduler) save() {
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, scheduled := range s.jobs {
+		jobs = append(jobs, scheduled.job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Id < jobs[j].Id
+	})
+	s.store.Save(jobs)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func parseSchedule(job Job) (Schedule, error) {
+	if len(job.Cron) > 0 {
+		return ParseCron(job.Cron)
+	}
+	return At(job.At), nil
+}
+
+func (s *scheduler) add(job Job) (Job, error) {
+	schedule, err := parseSchedule(job)
+	if err != nil {
+		return Job{}, err
+	}
+	job.Id = ksuid.New().String()
+	job.Next = schedule.Next(s.now())
+	if job.Next.IsZero() {
+		return Job{}, fmt.Errorf("job would never fire")
+	}
+	s.m.Lock()
+	err = func() error {
+		if err := s.load(); err != nil {
+			return err
+		}
+		if _, ok := s.handlers[job.Handler]; !ok {
+			return fmt.Errorf("unknown handler %s", job.Handler)
+		}
+		s.jobs[job.Id] = &scheduledJob{job: job, schedule: schedule}
+		s.save()
+		return nil
+	}()
+	s.m.Unlock()
+	return job, err
+}
+
+func (s *scheduler) Schedule(handler string, cron string, payload string) (Job, error) {
+	return s.add(Job{Handler: handler, Cron: cron, Payload: payload})
+}
+
+func (s *scheduler) ScheduleAt(handler string, at time.Time, payload string) (Job, error) {
+	return s.add(Job{Handler: handler, At: at, Payload: payload})
+}
+
+func (s *scheduler) Cancel(id string) error {
+	s.m.Lock()
+	err := func() error {
+		if err := s.load(); err != nil {
+			return err
+		}
+		if _, ok := s.jobs[id]; !ok {
+			return fmt.Errorf("unknown job %s", id)
+		}
+		delete(s.jobs, id)
+		s.save()
+		return nil
+	}()
+	s.m.Unlock()
+	return err
+}
+
+func (s *scheduler) Jobs() []Job {
+	s.m.Lock()
+	// a load error is returned by Start and by the methods modifying the jobs
+	_ = s.load()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, scheduled := range s.jobs {
+		jobs = append(jobs, scheduled.job)
+	}
+	s.m.Unlock()
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Next.Before(jobs[j].Next)
+	})
+	return jobs
+}
+
+// load restores the persisted jobs the first time it is called, before any of them can be overwritten by a save.
+// The lock must be held
+func (s *scheduler) load() error {
+	if s.loaded {
+		return s.loadErr
+	}
+	s.loaded = true
+	var jobs []Job
+	if err := s.store.Load(&jobs); err != nil && !errors.Is(err, io.EOF) {
+		s.loadErr = fmt.Errorf("cannot restore jobs: %w", err)
+		return s.loadErr
+	}
+	for _, job := range jobs {
+		schedule, err := parseSchedule(job)
+		if err != nil {
+			log.Printf("dropping job %s: %v", job.Id, err)
+			continue
+		}
+		s.jobs[job.Id] = &scheduledJob{job: job, schedule: schedule}
+	}
+	return nil
+}
+
+func (s *scheduler) restore() error {
+	s.m.Lock()
+	err := s.load()
+	s.m.Unlock()
+	return err
+}
+
+func (s *scheduler) next() time.Time {
+	var next time.Time
+	s.m.Lock()
+	for _, scheduled := range s.jobs {
+		if next.IsZero() || scheduled.job.Next.Before(next) {
+			next = scheduled.job.Next
+		}
+	}
+	s.m.Unlock()
+	return next
+}
+
+func (s *scheduler) run(ctx context.Context, job Job, handler Handler) {
+	messages, err := handler(ctx, job)
+	if err != nil {
+		log.Printf("job %s (%s) failed: %v", job.Id, job.Handler, err)
+	}
+	for _, message := range messages {
+		if err := s.sink.Send(message); err != nil {
+			log.Printf("job %s (%s): cannot send message: %v", job.Id, job.Handler, err)
+		}
+	}
+}
+
+// fireDue runs the jobs due at now and reschedules or removes them
+func (s *scheduler) fireDue(ctx context.Context, now time.Time) {
+	s.m.Lock()
+	changed := false
+	for id, scheduled := range s.jobs {
+		if scheduled.job.Next.After(now) {
+			continue
+		}
+		changed = true
+		if handler, ok := s.handlers[scheduled.job.Handler]; ok {
+			go s.run(ctx, scheduled.job, handler)
+		} else {
+			log.Printf("job %s has no handler %s", id, scheduled.job.Handler)
+		}
+		scheduled.job.Next = scheduled.schedule.Next(now)
+		if scheduled.job.Next.IsZero() {
+			delete(s.jobs, id)
+		}
+	}
+	if changed {
+		s.save()
+	}
+	s.m.Unlock()
+}
+
+func (s *scheduler) Start(ctx context.Context) error {
+	if err := s.restore(); err != nil {
+		return err
+	}
+	for {
+		s.fireDue(ctx, s.now())
+		var timer <-chan time.Time
+		var t *time.Timer
+		if next := s.next(); !next.IsZero() {
+			t = time.NewTimer(next.Sub(s.now()))
+			timer = t.C
+		}
+		select {
+		case <-ctx.Done():
+			if t != nil {
+				t.Stop()
+			}
+			return nil
+		case <-s.wake:
+		case <-timer:
+		}
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
+
+// NewScheduler creates a Scheduler persisting its jobs in store and sending their messages through sink
+func NewScheduler(store storage.Storage, sink command.ReplySink) Scheduler {
+	return &scheduler{
+		m:        &sync.Mutex{},
+		store:    store,
+		sink:     sink,
+		handlers: map[string]Handler{},
+		jobs:     map[string]*scheduledJob{},
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+var _ = NewScheduler
@@ -0,0 +1,155 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/raf924/connector-sdk/domain"
+	"github.com/raf924/connector-sdk/storage"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStorage struct {
+	m    sync.Mutex
+	data []byte
+}
+
+func (s *memoryStorage) Save(v interface{}) {
+	s.m.Lock()
+	s.data, _ = json.Marshal(v)
+	s.m.Unlock()
+}
+
+func (s *memoryStorage) Load(v interface{}) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.data == nil {
+		return nil
+	}
+	return json.Unmarshal(s.data, v)
+}
+
+type channelSink chan *domain.ClientMessage
+
+func (c channelSink) Send(message *domain.ClientMessage) error {
+	c <- message
+	return nil
+}
+
+func (c channelSink) SendAfter(time.Duration, *domain.ClientMessage) func() {
+	return func() {}
+}
+
+func (c channelSink) SendEvery(time.Duration, func() *domain.ClientMessage) func() {
+	return func() {}
+}
+
+func (c channelSink) Close() {
+}
+
+func remind(_ context.Context, job Job) ([]*domain.ClientMessage, error) {
+	return []*domain.ClientMessage{domain.NewClientMessage(job.Payload, nil, false)}, nil
+}
+
+func TestScheduler_ScheduleAt(t *testing.T) {
+	store := &memoryStorage{}
+	sink := make(channelSink, 1)
+	s := NewScheduler(store, sink)
+	s.Handle("remind", remind)
+	if _, err := s.ScheduleAt("unknown", time.Now().Add(time.Hour), ""); err == nil {
+		t.Errorf("expected error for unknown handler")
+	}
+	if _, err := s.ScheduleAt("remind", time.Now().Add(-time.Hour), ""); err == nil {
+		t.Errorf("expected error for job in the past")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = s.Start(ctx)
+	}()
+	if _, err := s.ScheduleAt("remind", time.Now().Add(20*time.Millisecond), "drink water"); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	select {
+	case message := <-sink:
+		if message.Message() != "drink water" {
+			t.Errorf("expected %v got %v", "drink water", message.Message())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected job to fire")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if jobs := s.Jobs(); len(jobs) != 0 {
+		t.Errorf("expected one-shot job to be removed got %v", jobs)
+	}
+}
+
+func TestScheduler_Restore(t *testing.T) {
+	store := &memoryStorage{}
+	s := NewScheduler(store, make(channelSink, 1))
+	s.Handle("remind", remind)
+	leapDay, err := s.Schedule("remind", "0 0 29 2 *", "leap day")
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	missed, _ := s.ScheduleAt("remind", time.Now().Add(time.Hour), "missed")
+
+	restarted := NewScheduler(store, make(channelSink, 1)).(*scheduler)
+	restarted.Handle("remind", remind)
+	restarted.now = func() time.Time {
+		return time.Now().Add(2 * time.Hour)
+	}
+	if err := restarted.restore(); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	if len(restarted.Jobs()) != 2 {
+		t.Fatalf("expected %v restored jobs got %v", 2, restarted.Jobs())
+	}
+	restarted.fireDue(context.Background(), restarted.now())
+	select {
+	case message := <-restarted.sink.(channelSink):
+		if message.Message() != missed.Payload {
+			t.Errorf("expected missed job to fire got %v", message.Message())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected missed job to fire")
+	}
+	jobs := restarted.Jobs()
+	if len(jobs) != 1 || jobs[0].Id != leapDay.Id {
+		t.Errorf("expected only the leap day job to remain got %v", jobs)
+	}
+}
+
+func TestScheduler_ScheduleBeforeStart(t *testing.T) {
+	store, err := storage.NewFileStorage(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	first := NewScheduler(store, make(channelSink, 1))
+	first.Handle("remind", remind)
+	stored, err := first.Schedule("remind", "0 9 * * *", "stored")
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	second := NewScheduler(store, make(channelSink, 1))
+	second.Handle("remind", remind)
+	added, err := second.Schedule("remind", "0 18 * * *", "added")
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := second.Start(ctx); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	restarted := NewScheduler(store, make(channelSink, 1))
+	ids := map[string]bool{}
+	for _, job := range restarted.Jobs() {
+		ids[job.Id] = true
+	}
+	if len(ids) != 2 || !ids[stored.Id] || !ids[added.Id] {
+		t.Errorf("expected jobs %v and %v got %v", stored.Id, added.Id, restarted.Jobs())
+	}
+}
@@ -14,25 +14,24 @@ type fsStorage struct {
 	m        *sync.Mutex
 }
 
+// Save writes v synchronously so that a later snapshot can never be overwritten by an earlier one
 func (f *fsStorage) Save(v interface{}) {
-	go func() {
-		f.m.Lock()
-		file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_WRONLY, os.ModePerm)
-		storageFunc := func() {
-			if err != nil {
-				log.Println(err)
-				return
-			}
-			err = json.NewEncoder(file).Encode(v)
-			if err != nil {
-				log.Println(err)
-				return
-			}
+	f.m.Lock()
+	file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	storageFunc := func() {
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		err = json.NewEncoder(file).Encode(v)
+		if err != nil {
+			log.Println(err)
+			return
 		}
-		storageFunc()
-		_ = file.Close()
-		f.m.Unlock()
-	}()
+	}
+	storageFunc()
+	_ = file.Close()
+	f.m.Unlock()
 }
 
 func (f *fsStorage) Load(v interface{}) error {